@@ -1,3 +1,3 @@
 module nikand.dev/go/hacked
 
-go 1.20
+go 1.21
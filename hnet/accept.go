@@ -0,0 +1,73 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+type (
+	backoff struct {
+		d time.Duration
+	}
+)
+
+const (
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+)
+
+// AcceptLoop accepts connections from l and calls handle for each of them.
+// handle is called synchronously, it should start a goroutine if needed.
+// Temporary errors like EMFILE and ECONNABORTED are retried
+// with exponential backoff the same way net/http.Server does.
+// It returns the context error on cancelation or the first fatal error.
+func AcceptLoop(ctx context.Context, l net.Listener, handle func(net.Conn)) error {
+	var b backoff
+
+	for {
+		c, err := Accept(ctx, l)
+		if err == nil {
+			b.reset()
+			handle(c)
+
+			continue
+		}
+
+		if ctx.Err() != nil || !IsTemporary(err) {
+			return err
+		}
+
+		err = b.wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *backoff) wait(ctx context.Context) error {
+	if b.d == 0 {
+		b.d = acceptBackoffMin
+	} else {
+		b.d = min(2*b.d, acceptBackoffMax)
+	}
+
+	return sleep(ctx, b.d)
+}
+
+func (b *backoff) reset() {
+	b.d = 0
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
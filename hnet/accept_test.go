@@ -0,0 +1,86 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type flakyListener struct {
+	*net.TCPListener
+
+	fails int
+}
+
+func TestAcceptLoop(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l.Close()
+
+	fl := &flakyListener{TCPListener: l, fails: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err == nil {
+			_ = c.Close()
+		}
+	}()
+
+	var accepted int
+
+	start := time.Now()
+
+	err = AcceptLoop(ctx, fl, func(c net.Conn) {
+		accepted++
+		_ = c.Close()
+
+		time.AfterFunc(10*time.Millisecond, cancel)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	if accepted != 1 || fl.fails != 0 {
+		t.Errorf("accepted %d, fails left %d", accepted, fl.fails)
+	}
+
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Errorf("expected backoff, took %v", d)
+	}
+}
+
+func TestAcceptLoopFatal(t *testing.T) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	_ = l.Close()
+
+	err = AcceptLoop(context.Background(), l, func(c net.Conn) {
+		t.Errorf("unexpected connection")
+	})
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected closed error, got %v", err)
+	}
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.fails > 0 {
+		l.fails--
+
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	}
+
+	return l.TCPListener.Accept()
+}
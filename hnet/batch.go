@@ -0,0 +1,110 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+)
+
+type (
+	// Message is a single datagram for ReadBatch and WriteBatch.
+	Message struct {
+		Buf  []byte
+		OOB  []byte
+		Addr netip.AddrPort // source on read, destination on write; zero for connected sockets
+
+		N     int // Buf bytes read or written
+		NN    int // OOB bytes read or written
+		Flags int // message flags set on read
+	}
+)
+
+// ReadBatch reads up to len(msgs) datagrams but aborts the operation on context cancelation.
+// It blocks until at least one datagram is received.
+// recvmmsg is used on Linux, other systems read a single datagram.
+// It returns the number of messages filled.
+func ReadBatch(ctx context.Context, c net.PacketConn, msgs []Message) (n int, err error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	defer DeadlineStopper(ctx, c.SetReadDeadline, readDeadline(c))()
+
+	n, err = readBatch(c, msgs)
+	if errors.Is(err, errors.ErrUnsupported) {
+		n, err = readBatchLoop(c, msgs)
+	}
+
+	err = FixErrorOp(ctx, "read", err)
+
+	return n, err
+}
+
+// WriteBatch writes datagrams but aborts the operation on context cancelation.
+// sendmmsg is used on Linux, other systems write messages one by one.
+// It returns the number of messages sent.
+func WriteBatch(ctx context.Context, c net.PacketConn, msgs []Message) (n int, err error) {
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	defer DeadlineStopper(ctx, c.SetWriteDeadline, writeDeadline(c))()
+
+	n, err = writeBatch(c, msgs)
+	if errors.Is(err, errors.ErrUnsupported) {
+		n, err = writeBatchLoop(c, msgs)
+	}
+
+	err = FixErrorOp(ctx, "write", err)
+
+	return n, err
+}
+
+func readBatchLoop(c net.PacketConn, msgs []Message) (n int, err error) {
+	m := &msgs[0]
+
+	if r, ok := c.(ReaderMsgUDPAddrPort); ok {
+		m.N, m.NN, m.Flags, m.Addr, err = r.ReadMsgUDPAddrPort(m.Buf, m.OOB)
+	} else {
+		var addr net.Addr
+
+		m.N, addr, err = c.ReadFrom(m.Buf)
+		m.NN, m.Flags, m.Addr = 0, 0, addrPort(addr)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+func writeBatchLoop(c net.PacketConn, msgs []Message) (n int, err error) {
+	for n = range msgs {
+		m := &msgs[n]
+
+		if w, ok := c.(WriterMsgUDPAddrPort); ok {
+			m.N, m.NN, err = w.WriteMsgUDPAddrPort(m.Buf, m.OOB, m.Addr)
+		} else if w, ok := c.(io.Writer); ok && !m.Addr.IsValid() {
+			m.N, err = w.Write(m.Buf)
+		} else {
+			m.N, err = c.WriteTo(m.Buf, net.UDPAddrFromAddrPort(m.Addr))
+		}
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return len(msgs), nil
+}
+
+func addrPort(a net.Addr) netip.AddrPort {
+	if a, ok := a.(*net.UDPAddr); ok {
+		return a.AddrPort()
+	}
+
+	return netip.AddrPort{}
+}
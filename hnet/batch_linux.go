@@ -0,0 +1,202 @@
+package hnet
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type (
+	mmsghdr struct {
+		Hdr syscall.Msghdr
+		Len uint32
+	}
+
+	mmsg struct {
+		hdrs  []mmsghdr
+		iovs  []syscall.Iovec
+		names []syscall.RawSockaddrInet6
+	}
+)
+
+func readBatch(c net.PacketConn, msgs []Message) (n int, err error) {
+	rc, err := rawConn(c)
+	if err != nil {
+		return 0, err
+	}
+
+	mm := newMmsg(msgs)
+
+	for i := range msgs {
+		mm.hdrs[i].Hdr.Name = (*byte)(unsafe.Pointer(&mm.names[i]))
+		mm.hdrs[i].Hdr.Namelen = syscall.SizeofSockaddrInet6
+	}
+
+	var serr syscall.Errno
+
+	err = rc.Read(func(fd uintptr) bool {
+		r, _, e := syscall.Syscall6(sysRecvmmsg, fd, uintptr(unsafe.Pointer(&mm.hdrs[0])), uintptr(len(mm.hdrs)), 0, 0, 0)
+		if e == syscall.EAGAIN {
+			return false
+		}
+
+		n, serr = int(r), e
+
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if serr != 0 {
+		return 0, os.NewSyscallError("recvmmsg", serr)
+	}
+
+	for i := range msgs[:n] {
+		h := &mm.hdrs[i]
+		m := &msgs[i]
+
+		m.N = int(h.Len)
+		m.NN = int(h.Hdr.Controllen)
+		m.Flags = int(h.Hdr.Flags)
+		m.Addr = sockaddrAddrPort(&mm.names[i])
+	}
+
+	return n, nil
+}
+
+func writeBatch(c net.PacketConn, msgs []Message) (n int, err error) {
+	rc, err := rawConn(c)
+	if err != nil {
+		return 0, err
+	}
+
+	var family int
+
+	err = rc.Control(func(fd uintptr) {
+		family = sockFamily(int(fd))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	mm := newMmsg(msgs)
+
+	for i, m := range msgs {
+		if !m.Addr.IsValid() {
+			continue
+		}
+
+		mm.hdrs[i].Hdr.Name = (*byte)(unsafe.Pointer(&mm.names[i]))
+		mm.hdrs[i].Hdr.Namelen = putSockaddr(&mm.names[i], m.Addr, family == syscall.AF_INET6)
+	}
+
+	var serr syscall.Errno
+
+	err = rc.Write(func(fd uintptr) bool {
+		for n < len(mm.hdrs) {
+			r, _, e := syscall.Syscall6(sysSendmmsg, fd, uintptr(unsafe.Pointer(&mm.hdrs[n])), uintptr(len(mm.hdrs)-n), 0, 0, 0)
+			if e == syscall.EAGAIN {
+				return false
+			}
+			if e != 0 {
+				serr = e
+				break
+			}
+
+			n += int(r)
+		}
+
+		return true
+	})
+
+	for i := range msgs[:n] {
+		msgs[i].N = int(mm.hdrs[i].Len)
+		msgs[i].NN = len(msgs[i].OOB)
+	}
+
+	if err != nil {
+		return n, err
+	}
+	if serr != 0 {
+		return n, os.NewSyscallError("sendmmsg", serr)
+	}
+
+	return n, nil
+}
+
+func newMmsg(msgs []Message) *mmsg {
+	mm := &mmsg{
+		hdrs:  make([]mmsghdr, len(msgs)),
+		iovs:  make([]syscall.Iovec, len(msgs)),
+		names: make([]syscall.RawSockaddrInet6, len(msgs)),
+	}
+
+	for i, m := range msgs {
+		h := &mm.hdrs[i].Hdr
+
+		if len(m.Buf) != 0 {
+			mm.iovs[i].Base = &m.Buf[0]
+			mm.iovs[i].SetLen(len(m.Buf))
+		}
+
+		h.Iov = &mm.iovs[i]
+		h.Iovlen = 1
+
+		if len(m.OOB) != 0 {
+			h.Control = &m.OOB[0]
+			h.SetControllen(len(m.OOB))
+		}
+	}
+
+	return mm
+}
+
+func rawConn(c net.PacketConn) (syscall.RawConn, error) {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	return sc.SyscallConn()
+}
+
+func sockaddrAddrPort(sa *syscall.RawSockaddrInet6) netip.AddrPort {
+	switch sa.Family {
+	case syscall.AF_INET:
+		sa4 := (*syscall.RawSockaddrInet4)(unsafe.Pointer(sa))
+
+		return netip.AddrPortFrom(netip.AddrFrom4(sa4.Addr), ntohs(sa4.Port))
+	case syscall.AF_INET6:
+		return netip.AddrPortFrom(netip.AddrFrom16(sa.Addr), ntohs(sa.Port))
+	}
+
+	return netip.AddrPort{}
+}
+
+func putSockaddr(sa *syscall.RawSockaddrInet6, a netip.AddrPort, v6 bool) uint32 {
+	if !v6 && a.Addr().Unmap().Is4() {
+		sa4 := (*syscall.RawSockaddrInet4)(unsafe.Pointer(sa))
+
+		sa4.Family = syscall.AF_INET
+		sa4.Port = ntohs(a.Port())
+		sa4.Addr = a.Addr().Unmap().As4()
+
+		return syscall.SizeofSockaddrInet4
+	}
+
+	sa.Family = syscall.AF_INET6
+	sa.Port = ntohs(a.Port())
+	sa.Addr = a.Addr().As16()
+
+	return syscall.SizeofSockaddrInet6
+}
+
+// ntohs swaps network and host byte order.
+func ntohs(p uint16) uint16 {
+	b := (*[2]byte)(unsafe.Pointer(&p))
+
+	return uint16(b[0])<<8 | uint16(b[1])
+}
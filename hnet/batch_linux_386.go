@@ -0,0 +1,7 @@
+package hnet
+
+// syscall package lacks SYS_SENDMMSG on 386.
+const (
+	sysRecvmmsg = 337
+	sysSendmmsg = 345
+)
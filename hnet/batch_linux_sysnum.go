@@ -0,0 +1,10 @@
+//go:build linux && !amd64 && !386
+
+package hnet
+
+import "syscall"
+
+const (
+	sysRecvmmsg = syscall.SYS_RECVMMSG
+	sysSendmmsg = syscall.SYS_SENDMMSG
+)
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package hnet
+
+import (
+	"errors"
+	"net"
+)
+
+func readBatch(c net.PacketConn, msgs []Message) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func writeBatch(c net.PacketConn, msgs []Message) (int, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,66 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	a, b := udpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	dst := b.LocalAddr().(*net.UDPAddr).AddrPort()
+
+	out := []Message{
+		{Buf: []byte("first"), Addr: dst},
+		{Buf: []byte("second"), Addr: dst},
+		{Buf: []byte("third"), Addr: dst},
+	}
+
+	n, err := WriteBatch(ctx, a, out)
+	if n != len(out) || err != nil {
+		t.Fatalf("write batch: %v %v", n, err)
+	}
+
+	in := make([]Message, 4)
+	for i := range in {
+		in[i].Buf = make([]byte, 16)
+	}
+
+	var got []string
+
+	for len(got) < len(out) {
+		n, err = ReadBatch(ctx, NewStoppablePacketConn(ctx, b), in)
+		if err != nil {
+			t.Fatalf("read batch: %v", err)
+		}
+
+		for _, m := range in[:n] {
+			if m.Addr != a.LocalAddr().(*net.UDPAddr).AddrPort() {
+				t.Errorf("addr: %v", m.Addr)
+			}
+
+			got = append(got, string(m.Buf[:m.N]))
+		}
+	}
+
+	for i, m := range out {
+		if got[i] != string(m.Buf) {
+			t.Errorf("message %d: %q, want %q", i, got[i], m.Buf)
+		}
+	}
+
+	cancel()
+
+	_, err = ReadBatch(ctx, b, in)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
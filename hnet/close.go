@@ -1,10 +1,26 @@
 package hnet
 
 import (
+	"errors"
 	"fmt"
 	"io"
 )
 
+type (
+	// Closers is a stack of resources closed in LIFO order.
+	//
+	//	var cs Closers
+	//	defer cs.CloseOnErr(&err)
+	//
+	//	f, err := os.Open(name)
+	//	if err != nil {
+	//		return nil, err
+	//	}
+	//
+	//	cs.Add(f)
+	Closers []func() error
+)
+
 // CloserOnErr closes resource on exit if error happened.
 //
 //	defer CloserOnErr(c, &err)
@@ -24,6 +40,7 @@ func Closer(c io.Closer, errp *error, msg string) {
 }
 
 // CloserFunc is for closing resource on exit and handling an error.
+// Close error is dropped if *errp is already set, use CloserFuncJoin to keep both.
 //
 //	defer CloserFunc(s.Finish, &err, "finish something")
 func CloserFunc(c func() error, errp *error, msg string) {
@@ -33,9 +50,38 @@ func CloserFunc(c func() error, errp *error, msg string) {
 	}
 }
 
+// CloserJoin is the same as Closer but if *errp is already set
+// the close error is joined to it with errors.Join instead of being dropped.
+//
+//	defer CloserJoin(f, &err, "close file")
+func CloserJoin(c io.Closer, errp *error, msg string) {
+	CloserFuncJoin(c.Close, errp, msg)
+}
+
+// CloserFuncJoin is the same as CloserFunc but it joins the close error
+// to already set *errp. errors.Is and errors.As match both of them.
+//
+//	defer CloserFuncJoin(w.Flush, &err, "flush")
+func CloserFuncJoin(c func() error, errp *error, msg string) {
+	err := c()
+	if err == nil {
+		return
+	}
+
+	err = fmt.Errorf("%v: %w", msg, err)
+
+	if *errp == nil {
+		*errp = err
+		return
+	}
+
+	*errp = errors.Join(*errp, err)
+}
+
 // CloserWriter closes writer and handles an error.
 // It calls CloseWriter if c has it and handles an error.
 // net.TCPConn has CloseWriter for example.
+// errors.ErrUnsupported is ignored.
 //
 //	defer CloserWriter(c, &err, "close writer")
 func CloserWriter(c any, errp *error, msg string) {
@@ -46,11 +92,15 @@ func CloserWriter(c any, errp *error, msg string) {
 		return
 	}
 
-	CloserFunc(cw.CloseWrite, errp, msg)
+	CloserFunc(func() error {
+		return ignoreUnsupported(cw.CloseWrite())
+	}, errp, msg)
 }
 
 // CloseWriter check if CloseWriter exists and calls it.
 // net.TCPConn has that method for example.
+// errors.ErrUnsupported is ignored, so wrappers like MeteredConn
+// around a connection without CloseWrite are handled as well.
 func CloseWriter(c any) error {
 	cw, ok := c.(interface {
 		CloseWrite() error
@@ -59,5 +109,55 @@ func CloseWriter(c any) error {
 		return nil
 	}
 
-	return cw.CloseWrite()
+	return ignoreUnsupported(cw.CloseWrite())
+}
+
+func ignoreUnsupported(err error) error {
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+
+	return err
+}
+
+// Add pushes c to the stack.
+func (cs *Closers) Add(c io.Closer) {
+	*cs = append(*cs, c.Close)
+}
+
+// AddFunc pushes f to the stack.
+func (cs *Closers) AddFunc(f func() error) {
+	*cs = append(*cs, f)
+}
+
+// Close closes all the resources in reverse order and empties the stack.
+// All the errors are joined.
+func (cs *Closers) Close() error {
+	var errs []error
+
+	for i := len(*cs) - 1; i >= 0; i-- {
+		if err := (*cs)[i](); err != nil {
+			errs = append(errs, err)
+		}
+
+		(*cs)[i] = nil
+	}
+
+	*cs = (*cs)[:0]
+
+	return errors.Join(errs...)
+}
+
+// CloseOnErr closes all the resources if error happened.
+// Close errors are joined to the original one.
+//
+//	defer cs.CloseOnErr(&err)
+func (cs *Closers) CloseOnErr(errp *error) {
+	if *errp == nil {
+		return
+	}
+
+	if err := cs.Close(); err != nil {
+		*errp = errors.Join(*errp, err)
+	}
 }
@@ -0,0 +1,66 @@
+package hnet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClosers(t *testing.T) {
+	var order []int
+
+	e1 := errors.New("first")
+	e3 := errors.New("third")
+
+	var cs Closers
+
+	cs.AddFunc(func() error { order = append(order, 1); return e1 })
+	cs.AddFunc(func() error { order = append(order, 2); return nil })
+	cs.AddFunc(func() error { order = append(order, 3); return e3 })
+
+	err := errors.New("construct")
+	orig := err
+
+	cs.CloseOnErr(&err)
+
+	if len(order) != 3 || order[0] != 3 || order[2] != 1 {
+		t.Errorf("order: %v", order)
+	}
+
+	if !errors.Is(err, orig) || !errors.Is(err, e1) || !errors.Is(err, e3) {
+		t.Errorf("joined error: %v", err)
+	}
+
+	if len(cs) != 0 {
+		t.Errorf("expected empty stack")
+	}
+
+	cs.AddFunc(func() error { t.Errorf("unexpected close"); return nil })
+
+	err = nil
+	cs.CloseOnErr(&err)
+}
+
+func TestCloserFuncJoin(t *testing.T) {
+	orig := errors.New("write")
+	closeErr := errors.New("flush")
+
+	err := orig
+
+	CloserFuncJoin(func() error { return closeErr }, &err, "close file")
+
+	if !errors.Is(err, orig) || !errors.Is(err, closeErr) {
+		t.Errorf("joined error: %v", err)
+	}
+
+	if exp := "write\nclose file: flush"; err.Error() != exp {
+		t.Errorf("message: %q, want %q", err.Error(), exp)
+	}
+
+	err = nil
+
+	CloserFuncJoin(func() error { return closeErr }, &err, "close file")
+
+	if err == nil || err.Error() != "close file: flush" {
+		t.Errorf("single error: %v", err)
+	}
+}
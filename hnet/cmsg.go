@@ -0,0 +1,49 @@
+package hnet
+
+import (
+	"net/netip"
+	"time"
+)
+
+type (
+	// ControlMessage is an IP level socket control message
+	// for ReadMsgUDPAddrPort and WriteMsgUDPAddrPort.
+	// Zero fields are not sent.
+	ControlMessage struct {
+		Dst     netip.Addr // packet destination address on read, source address on write
+		IfIndex int        // interface index
+
+		TTL int // TTL or hop limit
+		TOS int // TOS or traffic class including ECN bits
+
+		Timestamp time.Time // receive time, read only
+	}
+
+	// ControlFlags selects control messages to receive.
+	ControlFlags int
+)
+
+const (
+	FlagPacketInfo ControlFlags = 1 << iota // Dst and IfIndex
+	FlagTTL
+	FlagTOS
+	FlagTimestamp
+)
+
+// ECN codepoints, the lowest TOS bits.
+const (
+	ECNNotECT = 0b00
+	ECNECT1   = 0b01
+	ECNECT0   = 0b10
+	ECNCE     = 0b11
+
+	ECNMask = 0b11
+)
+
+// ControlMessageBufSize is enough to receive all the supported control messages.
+const ControlMessageBufSize = 256
+
+// ECN returns ECN bits of the TOS field.
+func (cm *ControlMessage) ECN() int {
+	return cm.TOS & ECNMask
+}
@@ -0,0 +1,198 @@
+package hnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var errMalformedCmsg = errors.New("malformed control message")
+
+// appendCmsg appends control message header and reserves n bytes of data.
+// It returns extended buffer and the data part to fill.
+func appendCmsg(b []byte, level, typ int32, n int) ([]byte, []byte) {
+	st := len(b)
+	b = append(b, make([]byte, syscall.CmsgSpace(n))...)
+
+	h := (*syscall.Cmsghdr)(unsafe.Pointer(&b[st]))
+	h.Level = level
+	h.Type = typ
+	h.SetLen(syscall.CmsgLen(n))
+
+	return b, b[st+syscall.CmsgLen(0) : st+syscall.CmsgLen(n)]
+}
+
+// walkCmsgs calls f for each control message in oob.
+func walkCmsgs(oob []byte, f func(level, typ int32, data []byte)) error {
+	for len(oob) >= syscall.SizeofCmsghdr {
+		h := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+
+		l := int(h.Len)
+		if l < syscall.CmsgLen(0) || l > len(oob) {
+			return errMalformedCmsg
+		}
+
+		f(h.Level, h.Type, oob[syscall.CmsgLen(0):l])
+
+		l = syscall.CmsgSpace(l - syscall.CmsgLen(0))
+		oob = oob[min(l, len(oob)):]
+	}
+
+	return nil
+}
+
+// Marshal appends control messages to b.
+// v6 selects IPv6 level messages, it must match the socket family.
+// It doesn't allocate if b has enough capacity.
+func (cm *ControlMessage) Marshal(b []byte, v6 bool) []byte {
+	var data []byte
+
+	if v6 {
+		if cm.Dst.IsValid() || cm.IfIndex != 0 {
+			b, data = appendCmsg(b, syscall.IPPROTO_IPV6, syscall.IPV6_PKTINFO, syscall.SizeofInet6Pktinfo)
+
+			pi := (*syscall.Inet6Pktinfo)(unsafe.Pointer(&data[0]))
+			pi.Ifindex = uint32(cm.IfIndex)
+
+			if cm.Dst.IsValid() {
+				pi.Addr = cm.Dst.As16()
+			}
+		}
+
+		if cm.TTL != 0 {
+			b = appendCmsgInt(b, syscall.IPPROTO_IPV6, syscall.IPV6_HOPLIMIT, cm.TTL)
+		}
+
+		if cm.TOS != 0 {
+			b = appendCmsgInt(b, syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS, cm.TOS)
+		}
+
+		return b
+	}
+
+	if cm.Dst.IsValid() || cm.IfIndex != 0 {
+		b, data = appendCmsg(b, syscall.IPPROTO_IP, syscall.IP_PKTINFO, syscall.SizeofInet4Pktinfo)
+
+		pi := (*syscall.Inet4Pktinfo)(unsafe.Pointer(&data[0]))
+		pi.Ifindex = int32(cm.IfIndex)
+
+		if cm.Dst.IsValid() {
+			pi.Spec_dst = cm.Dst.Unmap().As4()
+		}
+	}
+
+	if cm.TTL != 0 {
+		b = appendCmsgInt(b, syscall.IPPROTO_IP, syscall.IP_TTL, cm.TTL)
+	}
+
+	if cm.TOS != 0 {
+		b = appendCmsgInt(b, syscall.IPPROTO_IP, syscall.IP_TOS, cm.TOS)
+	}
+
+	return b
+}
+
+// Unmarshal parses control messages received with ReadMsgUDP.
+// Unknown messages are skipped.
+// It doesn't allocate.
+func (cm *ControlMessage) Unmarshal(oob []byte) error {
+	*cm = ControlMessage{}
+
+	return walkCmsgs(oob, cm.parse)
+}
+
+func (cm *ControlMessage) parse(level, typ int32, data []byte) {
+	switch {
+	case level == syscall.IPPROTO_IP && typ == syscall.IP_PKTINFO && len(data) >= syscall.SizeofInet4Pktinfo:
+		pi := (*syscall.Inet4Pktinfo)(unsafe.Pointer(&data[0]))
+
+		cm.Dst = netip.AddrFrom4(pi.Addr)
+		cm.IfIndex = int(pi.Ifindex)
+	case level == syscall.IPPROTO_IP && typ == syscall.IP_TTL && len(data) >= 4:
+		cm.TTL = int(int32(binary.NativeEndian.Uint32(data)))
+	case level == syscall.IPPROTO_IP && typ == syscall.IP_TOS && len(data) >= 1:
+		cm.TOS = int(data[0])
+	case level == syscall.IPPROTO_IPV6 && typ == syscall.IPV6_PKTINFO && len(data) >= syscall.SizeofInet6Pktinfo:
+		pi := (*syscall.Inet6Pktinfo)(unsafe.Pointer(&data[0]))
+
+		cm.Dst = netip.AddrFrom16(pi.Addr)
+		cm.IfIndex = int(pi.Ifindex)
+	case level == syscall.IPPROTO_IPV6 && typ == syscall.IPV6_HOPLIMIT && len(data) >= 4:
+		cm.TTL = int(int32(binary.NativeEndian.Uint32(data)))
+	case level == syscall.IPPROTO_IPV6 && typ == syscall.IPV6_TCLASS && len(data) >= 4:
+		cm.TOS = int(int32(binary.NativeEndian.Uint32(data)))
+	case level == syscall.SOL_SOCKET && typ == syscall.SCM_TIMESTAMPNS && len(data) >= int(unsafe.Sizeof(syscall.Timespec{})):
+		ts := (*syscall.Timespec)(unsafe.Pointer(&data[0]))
+
+		cm.Timestamp = time.Unix(ts.Unix())
+	}
+}
+
+// SetControlMessage enables or disables receiving of the selected control messages.
+// Both IPv4 and IPv6 options are set on dual-stack IPv6 sockets.
+func SetControlMessage(c syscall.Conn, flags ControlFlags, on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+
+	var err error
+
+	cerr := control(c, func(fd int) {
+		v6 := sockFamily(fd) == syscall.AF_INET6
+
+		set := func(level, opt int, flag ControlFlags, v4 bool) {
+			if flags&flag == 0 || err != nil {
+				return
+			}
+
+			e := syscall.SetsockoptInt(fd, level, opt, v)
+			if e != nil && !(v4 && v6) {
+				err = wrapSyscallError("setsockopt", e)
+			}
+		}
+
+		if v6 {
+			set(syscall.IPPROTO_IPV6, syscall.IPV6_RECVPKTINFO, FlagPacketInfo, false)
+			set(syscall.IPPROTO_IPV6, syscall.IPV6_RECVHOPLIMIT, FlagTTL, false)
+			set(syscall.IPPROTO_IPV6, syscall.IPV6_RECVTCLASS, FlagTOS, false)
+		}
+
+		set(syscall.IPPROTO_IP, syscall.IP_PKTINFO, FlagPacketInfo, true)
+		set(syscall.IPPROTO_IP, syscall.IP_RECVTTL, FlagTTL, true)
+		set(syscall.IPPROTO_IP, syscall.IP_RECVTOS, FlagTOS, true)
+
+		set(syscall.SOL_SOCKET, syscall.SO_TIMESTAMPNS, FlagTimestamp, false)
+	})
+	if cerr != nil {
+		return cerr
+	}
+
+	return err
+}
+
+func appendCmsgInt(b []byte, level, typ int32, v int) []byte {
+	b, data := appendCmsg(b, level, typ, 4)
+	binary.NativeEndian.PutUint32(data, uint32(int32(v)))
+
+	return b
+}
+
+func sockFamily(fd int) int {
+	sa, err := syscall.Getsockname(fd)
+	if err != nil {
+		return 0
+	}
+
+	switch sa.(type) {
+	case *syscall.SockaddrInet4:
+		return syscall.AF_INET
+	case *syscall.SockaddrInet6:
+		return syscall.AF_INET6
+	default:
+		return 0
+	}
+}
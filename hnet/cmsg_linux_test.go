@@ -0,0 +1,81 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestControlMessage(t *testing.T) {
+	a, b := udpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	err := SetControlMessage(b, FlagPacketInfo|FlagTTL|FlagTOS|FlagTimestamp, true)
+	if err != nil {
+		t.Fatalf("set control message: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	out := ControlMessage{TTL: 10, TOS: 0x20 | ECNECT0}
+	oob := out.Marshal(make([]byte, 0, ControlMessageBufSize), false)
+
+	_, _, err = WriteMsgUDPAddrPort(ctx, a, []byte("data"), oob, b.LocalAddr().(*net.UDPAddr).AddrPort())
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buf := make([]byte, 16)
+	oob = make([]byte, ControlMessageBufSize)
+
+	_, oobn, _, _, err := ReadMsgUDPAddrPort(ctx, b, buf, oob)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	var in ControlMessage
+
+	err = in.Unmarshal(oob[:oobn])
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if in.Dst != netip.MustParseAddr("127.0.0.1") || in.IfIndex == 0 {
+		t.Errorf("packet info: %v %v", in.Dst, in.IfIndex)
+	}
+
+	if in.TTL != 10 || in.TOS != out.TOS || in.ECN() != ECNECT0 {
+		t.Errorf("ttl %v tos %#x", in.TTL, in.TOS)
+	}
+
+	if d := time.Since(in.Timestamp); d < 0 || d > time.Minute {
+		t.Errorf("timestamp: %v", in.Timestamp)
+	}
+}
+
+func TestControlMessageRoundtrip(t *testing.T) {
+	out := ControlMessage{Dst: netip.MustParseAddr("::1"), IfIndex: 1, TTL: 5, TOS: 1}
+
+	var in ControlMessage
+
+	err := in.Unmarshal(out.Marshal(nil, true))
+	if err != nil || in != out {
+		t.Errorf("roundtrip: %+v %v", in, err)
+	}
+}
+
+func BenchmarkControlMessage(b *testing.B) {
+	b.ReportAllocs()
+
+	cm := ControlMessage{Dst: netip.MustParseAddr("::1"), TTL: 5, TOS: 1}
+	buf := make([]byte, 0, ControlMessageBufSize)
+
+	for i := 0; i < b.N; i++ {
+		oob := cm.Marshal(buf[:0], true)
+		_ = cm.Unmarshal(oob)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package hnet
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Marshal returns b unchanged as control messages are only supported on Linux.
+func (cm *ControlMessage) Marshal(b []byte, v6 bool) []byte {
+	return b
+}
+
+// Unmarshal returns errors.ErrUnsupported as control messages are only supported on Linux.
+func (cm *ControlMessage) Unmarshal(oob []byte) error {
+	*cm = ControlMessage{}
+
+	return errors.ErrUnsupported
+}
+
+// SetControlMessage returns errors.ErrUnsupported as control messages are only supported on Linux.
+func SetControlMessage(c syscall.Conn, flags ControlFlags, on bool) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,221 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+type (
+	writerOnly struct {
+		io.Writer
+	}
+
+	readerOnly struct {
+		io.Reader
+	}
+
+	// stoppableSocketConn wraps *net.TCPConn, *net.UnixConn and alike.
+	stoppableSocketConn struct {
+		StoppableConn
+	}
+
+	// stoppableHalfConn wraps connections with CloseWrite and CloseRead.
+	stoppableHalfConn struct {
+		StoppableConn
+	}
+
+	// stoppableWriteCloserConn wraps connections with CloseWrite only, such as *tls.Conn.
+	stoppableWriteCloserConn struct {
+		StoppableConn
+	}
+
+	// stoppableFileConn wraps *net.UDPConn, *net.IPConn and alike.
+	stoppableFileConn struct {
+		StoppableConn
+	}
+
+	stoppableConner interface {
+		stoppable() StoppableConn
+	}
+
+	fileConn interface {
+		syscall.Conn
+		File() (*os.File, error)
+	}
+)
+
+// NewStoppableConn wraps connection to have the same interface,
+// but returned connection aborts on context cancellation.
+// CloseWrite, CloseRead, SyscallConn and File are forwarded
+// if the wrapped connection has them.
+func NewStoppableConn(ctx context.Context, c net.Conn) net.Conn {
+	sc := StoppableConn{
+		Context: ctx,
+		Conn:    c,
+		dl:      &deadlines{},
+	}
+
+	_, cw := c.(interface{ CloseWrite() error })
+	_, cr := c.(interface{ CloseRead() error })
+	_, f := c.(fileConn)
+
+	switch {
+	case cw && cr && f:
+		return stoppableSocketConn{sc}
+	case cw && cr:
+		return stoppableHalfConn{sc}
+	case cw:
+		return stoppableWriteCloserConn{sc}
+	case f:
+		return stoppableFileConn{sc}
+	}
+
+	return sc
+}
+
+// NetConn returns the wrapped connection.
+func (c StoppableConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c StoppableConn) stoppable() StoppableConn { return c }
+
+// CloseWrite shuts down the writing side of the connection.
+func (c stoppableSocketConn) CloseWrite() error { return closeWrite(c.Conn) }
+
+// CloseRead shuts down the reading side of the connection.
+func (c stoppableSocketConn) CloseRead() error { return closeRead(c.Conn) }
+
+func (c stoppableSocketConn) SyscallConn() (syscall.RawConn, error) { return syscallConn(c.Conn) }
+func (c stoppableSocketConn) File() (*os.File, error)               { return c.Conn.(fileConn).File() }
+
+func (c stoppableHalfConn) CloseWrite() error { return closeWrite(c.Conn) }
+func (c stoppableHalfConn) CloseRead() error  { return closeRead(c.Conn) }
+
+func (c stoppableWriteCloserConn) CloseWrite() error { return closeWrite(c.Conn) }
+
+func (c stoppableFileConn) SyscallConn() (syscall.RawConn, error) { return syscallConn(c.Conn) }
+func (c stoppableFileConn) File() (*os.File, error)               { return c.Conn.(fileConn).File() }
+
+// ReadFrom implements io.ReaderFrom.
+// It uses the wrapped connection ReadFrom if it has one,
+// so splice and sendfile are used for *net.TCPConn.
+// If r is a StoppableConn, it's unwrapped to keep the fast path,
+// but its context is still respected.
+func (c StoppableConn) ReadFrom(r io.Reader) (n int64, err error) {
+	rf, ok := c.Conn.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(writerOnly{c}, r)
+	}
+
+	src, sctx, release := unwrapReader(context.Background(), r)
+	defer release()
+
+	defer DeadlineStopper(c.Context, c.Conn.SetWriteDeadline, c.WriteDeadline)()
+
+	n, err = rf.ReadFrom(src)
+	err = FixErrorOp(c.Context, "readfrom", err)
+
+	if sctx != nil {
+		err = FixErrorOp(sctx, "readfrom", err)
+	}
+
+	return n, err
+}
+
+// WriteTo implements io.WriterTo.
+// It uses the wrapped connection WriteTo if it has one.
+// If w is a StoppableConn, it's unwrapped the same way as in ReadFrom.
+func (c StoppableConn) WriteTo(w io.Writer) (n int64, err error) {
+	wt, ok := c.Conn.(io.WriterTo)
+	if !ok {
+		return io.Copy(w, readerOnly{c})
+	}
+
+	dst, dctx, release := unwrapWriter(context.Background(), w)
+	defer release()
+
+	defer DeadlineStopper(c.Context, c.Conn.SetReadDeadline, c.ReadDeadline)()
+
+	n, err = wt.WriteTo(dst)
+	err = FixErrorOp(c.Context, "writeto", err)
+
+	if dctx != nil {
+		err = FixErrorOp(dctx, "writeto", err)
+	}
+
+	return n, err
+}
+
+func closeWrite(c any) error {
+	cw, ok := c.(interface {
+		CloseWrite() error
+	})
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	return cw.CloseWrite()
+}
+
+func closeRead(c any) error {
+	cr, ok := c.(interface {
+		CloseRead() error
+	})
+	if !ok {
+		return errors.ErrUnsupported
+	}
+
+	return cr.CloseRead()
+}
+
+func syscallConn(c any) (syscall.RawConn, error) {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+
+	return sc.SyscallConn()
+}
+
+// unwrapReader unwraps StoppableConn and installs both ctx and its own context hooks
+// on the wrapped conn for the whole operation.
+func unwrapReader(ctx context.Context, r io.Reader) (io.Reader, context.Context, func()) {
+	switch r := r.(type) {
+	case stoppableConner:
+		sc := r.stoppable()
+
+		return sc.Conn, sc.Context, deadlineStopper(ctx, sc.Context, sc.Conn.SetReadDeadline, sc.ReadDeadline)
+	case *io.LimitedReader:
+		s, ok := r.R.(stoppableConner)
+		if !ok {
+			break
+		}
+
+		sc := s.stoppable()
+		lr := &io.LimitedReader{R: sc.Conn, N: r.N}
+		stop := deadlineStopper(ctx, sc.Context, sc.Conn.SetReadDeadline, sc.ReadDeadline)
+
+		return lr, sc.Context, func() {
+			stop()
+			r.N = lr.N
+		}
+	}
+
+	return r, nil, nop
+}
+
+func unwrapWriter(ctx context.Context, w io.Writer) (io.Writer, context.Context, func()) {
+	s, ok := w.(stoppableConner)
+	if !ok {
+		return w, nil, nop
+	}
+
+	sc := s.stoppable()
+
+	return sc.Conn, sc.Context, deadlineStopper(ctx, sc.Context, sc.Conn.SetWriteDeadline, sc.WriteDeadline)
+}
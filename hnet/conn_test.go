@@ -0,0 +1,107 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestStoppableConnForward(t *testing.T) {
+	ctx := context.Background()
+
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	sa := NewStoppableConn(ctx, a)
+	sb := NewStoppableConn(ctx, b)
+
+	if _, ok := sa.(syscall.Conn); !ok {
+		t.Errorf("expected syscall.Conn")
+	}
+
+	go func() {
+		_, _ = sa.Write([]byte("hello"))
+		_ = CloseWriter(sa)
+	}()
+
+	data, err := io.ReadAll(sb)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("read all: %q %v", data, err)
+	}
+}
+
+func TestStoppableConnUnsupported(t *testing.T) {
+	p, q := net.Pipe()
+	defer p.Close()
+	defer q.Close()
+
+	c := NewStoppableConn(context.Background(), p)
+
+	if _, ok := c.(interface{ CloseWrite() error }); ok {
+		t.Errorf("CloseWrite of a conn without one")
+	}
+
+	if _, ok := c.(syscall.Conn); ok {
+		t.Errorf("SyscallConn of a conn without one")
+	}
+
+	if err := CloseWriter(c); err != nil {
+		t.Errorf("close writer: %v", err)
+	}
+}
+
+func TestStoppableConnReadFromCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	c, d := tcpPair(t)
+	defer c.Close()
+	defer d.Close()
+
+	dst := NewStoppableConn(context.Background(), c)
+	src := NewStoppableConn(ctx, b)
+
+	go func() {
+		_, _ = a.Write([]byte("data"))
+
+		buf := make([]byte, 4)
+		_, _ = io.ReadFull(d, buf)
+
+		cancel()
+	}()
+
+	n, err := io.Copy(dst, src)
+	if n != 4 || !errors.Is(err, context.Canceled) {
+		t.Errorf("copy: %v %v", n, err)
+	}
+}
+
+func tcpPair(tb testing.TB) (net.Conn, net.Conn) {
+	tb.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("listen: %v", err)
+	}
+
+	defer l.Close()
+
+	a, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		tb.Fatalf("dial: %v", err)
+	}
+
+	b, err := l.Accept()
+	if err != nil {
+		tb.Fatalf("accept: %v", err)
+	}
+
+	return a, b
+}
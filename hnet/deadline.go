@@ -0,0 +1,44 @@
+package hnet
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// deadlines tracks deadlines set by the user on a wrapped connection,
+	// so they can be restored after the context deadline or cancellation.
+	deadlines struct {
+		mu    sync.Mutex
+		read  time.Time
+		write time.Time
+	}
+)
+
+func (d *deadlines) setRead(t time.Time) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	d.read = t
+}
+
+func (d *deadlines) setWrite(t time.Time) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	d.write = t
+}
+
+func (d *deadlines) readDeadline() time.Time {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	return d.read
+}
+
+func (d *deadlines) writeDeadline() time.Time {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	return d.write
+}
@@ -0,0 +1,118 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+type (
+	DialOptions struct {
+		// Dialer is the base dialer if set.
+		// Use it for KeepAlive, Control, Resolver and other settings.
+		Dialer *net.Dialer
+
+		// LocalAddr is the local address to bind to.
+		LocalAddr net.Addr
+
+		// AttemptTimeout limits each dial attempt, including all resolved addresses.
+		AttemptTimeout time.Duration
+
+		// FallbackDelay is the happy eyeballs delay before racing the fallback address family.
+		// Zero means net.Dialer default, negative disables racing.
+		FallbackDelay time.Duration
+
+		// Retries is the number of retries after the first failed attempt.
+		Retries int
+
+		// RetryBackoff is the delay before the first retry, it's doubled after each one.
+		RetryBackoff time.Duration
+
+		// Retry reports whether the error is worth retrying.
+		// All the errors are retried if nil.
+		Retry func(err error) bool
+	}
+)
+
+// Dial connects to the address and returns StoppableConn bound to ctx.
+// ctx is used for dialing and for all the connection operations after that,
+// so it should live as long as the connection.
+// opts may be nil.
+func Dial(ctx context.Context, network, addr string, opts *DialOptions) (net.Conn, error) {
+	c, err := dial(ctx, network, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStoppableConn(ctx, c), nil
+}
+
+// DialUDP is the same as Dial but for udp, udp4 and udp6 networks only.
+// Returned connection is connected to addr and has all the *net.UDPConn methods,
+// see NewStoppableUDPConn.
+func DialUDP(ctx context.Context, network, addr string, opts *DialOptions) (UDPConn, error) {
+	switch network {
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, &net.OpError{Op: "dial", Net: network, Err: net.UnknownNetworkError(network)}
+	}
+
+	c, err := dial(ctx, network, addr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStoppableUDPConn(ctx, c.(*net.UDPConn)), nil
+}
+
+func dial(ctx context.Context, network, addr string, opts *DialOptions) (net.Conn, error) {
+	if opts == nil {
+		opts = &DialOptions{}
+	}
+
+	var d net.Dialer
+
+	if opts.Dialer != nil {
+		d = *opts.Dialer
+	}
+
+	if opts.LocalAddr != nil {
+		d.LocalAddr = opts.LocalAddr
+	}
+
+	if opts.FallbackDelay != 0 {
+		d.FallbackDelay = opts.FallbackDelay
+	}
+
+	delay := opts.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		c, err := dialAttempt(ctx, &d, network, addr, opts.AttemptTimeout)
+		if err == nil {
+			return c, nil
+		}
+
+		if ctx.Err() != nil || attempt >= opts.Retries || opts.Retry != nil && !opts.Retry(err) {
+			return nil, err
+		}
+
+		if delay != 0 {
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+
+			delay *= 2
+		}
+	}
+}
+
+func dialAttempt(ctx context.Context, d *net.Dialer, network, addr string, timeout time.Duration) (net.Conn, error) {
+	if timeout != 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return d.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,113 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := Dial(ctx, "tcp", l.Addr().String(), &DialOptions{AttemptTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	defer c.Close()
+
+	if _, ok := c.(interface {
+		ReadDeadliner
+		CloseWrite() error
+	}); !ok {
+		t.Errorf("expected StoppableConn with CloseWrite, got %T", c)
+	}
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err = c.Read(make([]byte, 10))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func TestDialRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	var attempts int
+
+	_, err = Dial(context.Background(), "tcp", addr, &DialOptions{
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+		Retry: func(err error) bool {
+			attempts++
+			return true
+		},
+	})
+	if err == nil || attempts != 2 {
+		t.Errorf("expected error after retries: %v, attempts %d", err, attempts)
+	}
+}
+
+func TestDialUDP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, peer := udpPair(t)
+	defer peer.Close()
+
+	c, err := DialUDP(ctx, "udp", peer.LocalAddr().String(), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	defer c.Close()
+
+	_, _, err = c.WriteMsgUDPAddrPort([]byte("ping"), nil, netip.AddrPort{})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buf := make([]byte, 16)
+
+	n, addr, err := peer.ReadFromUDPAddrPort(buf)
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Fatalf("peer read: %q %v", buf[:n], err)
+	}
+
+	_, _ = peer.WriteToUDPAddrPort([]byte("pong"), addr)
+
+	n, _, _, _, err = c.ReadMsgUDPAddrPort(buf, nil)
+	if err != nil || string(buf[:n]) != "pong" {
+		t.Errorf("read: %q %v", buf[:n], err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, _, _, _, err = c.ReadMsgUDPAddrPort(buf, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	_, err = DialUDP(context.Background(), "tcp", peer.LocalAddr().String(), nil)
+	if err == nil {
+		t.Errorf("expected unknown network error")
+	}
+}
@@ -0,0 +1,112 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+)
+
+type (
+	// AbortError is returned by the wrappers if the operation was aborted
+	// because of context cancelation or deadline.
+	// errors.Is matches the cancelation cause, the context error
+	// and the original error (os.ErrDeadlineExceeded).
+	AbortError struct {
+		Op     string // read, write, accept, ...; may be empty
+		Cause  error  // context.Cause
+		CtxErr error  // ctx.Err
+		Err    error  // error returned by the aborted operation
+	}
+)
+
+func (e *AbortError) Error() string {
+	if e.Op == "" {
+		return e.Cause.Error()
+	}
+
+	return e.Op + ": " + e.Cause.Error()
+}
+
+func (e *AbortError) Unwrap() []error {
+	if e.Cause == e.CtxErr {
+		return []error{e.Cause, e.Err}
+	}
+
+	return []error{e.Cause, e.CtxErr, e.Err}
+}
+
+// Timeout reports whether the context deadline has passed.
+func (e *AbortError) Timeout() bool {
+	return errors.Is(e.CtxErr, context.DeadlineExceeded)
+}
+
+// IsClosed reports whether err is caused by using closed connection or pipe.
+// These errors are usually expected on shutdown and not worth reporting.
+func IsClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe)
+}
+
+// IsTimeout reports whether err is caused by a deadline
+// set on the connection or by the context.
+// Operations aborted by context cancelation are not timeouts.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var te interface{ Timeout() bool }
+
+	return errors.As(err, &te) && te.Timeout()
+}
+
+// IsConnReset reports whether connection was reset or aborted by the peer.
+func IsConnReset(err error) bool {
+	return isErrno(err, resetErrnos)
+}
+
+// IsRefused reports whether connection was refused by the peer.
+func IsRefused(err error) bool {
+	return isErrno(err, refusedErrnos)
+}
+
+// IsTemporary reports whether the operation may succeed if retried later.
+// Resource exhaustion errors like EMFILE and ENOBUFS are considered temporary.
+// Timeouts are not temporary, see IsTimeout.
+func IsTemporary(err error) bool {
+	if IsTimeout(err) {
+		return false
+	}
+
+	if isErrno(err, temporaryErrnos) {
+		return true
+	}
+
+	var te interface{ Temporary() bool }
+
+	return errors.As(err, &te) && te.Temporary()
+}
+
+// IsCanceled reports whether err is caused by context cancelation.
+func IsCanceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+func isErrno(err error, list []error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, e := range list {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+
+	return false
+}
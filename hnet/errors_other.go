@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package hnet
+
+var (
+	resetErrnos     []error
+	refusedErrnos   []error
+	temporaryErrnos []error
+)
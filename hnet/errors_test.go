@@ -0,0 +1,62 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestErrorClassification(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	_, err = net.Dial("tcp", addr)
+	if !IsRefused(err) || IsClosed(err) || IsTimeout(err) {
+		t.Errorf("refused: %v", err)
+	}
+
+	a, b := tcpPair(t)
+	defer b.Close()
+
+	_ = a.SetReadDeadline(time.Now())
+
+	_, err = a.Read(make([]byte, 1))
+	if !IsTimeout(err) || IsCanceled(err) || IsTemporary(err) {
+		t.Errorf("timeout: %v", err)
+	}
+
+	_ = a.Close()
+
+	_, err = a.Read(make([]byte, 1))
+	if !IsClosed(err) || IsConnReset(err) || IsTemporary(err) {
+		t.Errorf("closed: %v", err)
+	}
+
+	err = &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", resetErrnos[0])}
+	if !IsConnReset(err) || IsClosed(err) {
+		t.Errorf("reset: %v", err)
+	}
+
+	err = &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", temporaryErrnos[0])}
+	if !IsTemporary(err) {
+		t.Errorf("temporary: %v", err)
+	}
+
+	if !IsCanceled(context.Canceled) || !IsTimeout(context.DeadlineExceeded) || IsTimeout(nil) {
+		t.Errorf("context errors")
+	}
+
+	for _, err := range []error{os.ErrDeadlineExceeded, context.DeadlineExceeded, syscall.ETIMEDOUT} {
+		if !IsTimeout(err) || IsTemporary(err) {
+			t.Errorf("timeout is temporary: %v", err)
+		}
+	}
+}
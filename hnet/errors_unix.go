@@ -0,0 +1,11 @@
+//go:build unix
+
+package hnet
+
+import "syscall"
+
+var (
+	resetErrnos     = []error{syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE}
+	refusedErrnos   = []error{syscall.ECONNREFUSED}
+	temporaryErrnos = []error{syscall.EMFILE, syscall.ENFILE, syscall.ENOBUFS, syscall.ENOMEM, syscall.ECONNABORTED, syscall.ECONNRESET, syscall.EINTR}
+)
@@ -0,0 +1,16 @@
+package hnet
+
+import "syscall"
+
+// Winsock error codes missing in syscall package.
+const (
+	wsaEMFILE       = syscall.Errno(10024)
+	wsaENOBUFS      = syscall.Errno(10055)
+	wsaECONNREFUSED = syscall.Errno(10061)
+)
+
+var (
+	resetErrnos     = []error{syscall.WSAECONNRESET, syscall.WSAECONNABORTED, syscall.ERROR_NETNAME_DELETED}
+	refusedErrnos   = []error{wsaECONNREFUSED}
+	temporaryErrnos = []error{wsaEMFILE, wsaENOBUFS, syscall.WSAECONNABORTED, syscall.WSAECONNRESET}
+)
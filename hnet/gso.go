@@ -0,0 +1,24 @@
+package hnet
+
+// GSOMaxSegments is the maximum number of segments kernel accepts in one GSO send.
+const GSOMaxSegments = 64
+
+// SplitSegments splits UDP GRO coalesced buffer into datagrams of size bytes.
+// The last datagram may be shorter.
+// Datagrams are appended to dst and point into p.
+func SplitSegments(dst [][]byte, p []byte, size int) [][]byte {
+	if size <= 0 {
+		return append(dst, p)
+	}
+
+	for len(p) > size {
+		dst = append(dst, p[:size:size])
+		p = p[size:]
+	}
+
+	if len(p) != 0 {
+		dst = append(dst, p)
+	}
+
+	return dst
+}
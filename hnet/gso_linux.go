@@ -0,0 +1,83 @@
+package hnet
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+)
+
+const (
+	udpSegment = 103 // UDP_SEGMENT
+	udpGRO     = 104 // UDP_GRO
+)
+
+// AppendGSO appends UDP_SEGMENT control message to oob.
+// The kernel splits the payload into datagrams of size bytes.
+// Check SupportsGSO before using it.
+func AppendGSO(oob []byte, size int) []byte {
+	oob, data := appendCmsg(oob, syscall.IPPROTO_UDP, udpSegment, 2)
+	binary.NativeEndian.PutUint16(data, uint16(size))
+
+	return oob
+}
+
+// ParseGRO returns segment size from UDP_GRO control message
+// received with ReadMsgUDP if GRO is enabled.
+// ok is false if the datagram wasn't coalesced.
+func ParseGRO(oob []byte) (size int, ok bool) {
+	_ = walkCmsgs(oob, func(level, typ int32, data []byte) {
+		if level != syscall.IPPROTO_UDP || typ != udpGRO || len(data) < 4 {
+			return
+		}
+
+		size = int(int32(binary.NativeEndian.Uint32(data)))
+		ok = true
+	})
+
+	return
+}
+
+// SupportsGSO checks if the kernel supports UDP_SEGMENT on the socket.
+func SupportsGSO(c syscall.Conn) bool {
+	var err error
+
+	cerr := control(c, func(fd int) {
+		_, err = syscall.GetsockoptInt(fd, syscall.IPPROTO_UDP, udpSegment)
+	})
+
+	return cerr == nil && err == nil
+}
+
+// EnableGRO enables UDP_GRO on the socket.
+// An error means the kernel doesn't support it.
+func EnableGRO(c syscall.Conn) error {
+	var err error
+
+	cerr := control(c, func(fd int) {
+		err = syscall.SetsockoptInt(fd, syscall.IPPROTO_UDP, udpGRO, 1)
+	})
+	if cerr != nil {
+		return cerr
+	}
+
+	return wrapSyscallError("setsockopt", err)
+}
+
+func control(c syscall.Conn, f func(fd int)) error {
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	return rc.Control(func(fd uintptr) {
+		f(int(fd))
+	})
+}
+
+func wrapSyscallError(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return os.NewSyscallError(name, err)
+}
@@ -0,0 +1,57 @@
+package hnet
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGSOGRO(t *testing.T) {
+	a, b := udpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	if !SupportsGSO(a) {
+		t.Skip("GSO is not supported")
+	}
+
+	groErr := EnableGRO(b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	payload := bytes.Repeat([]byte("0123456789"), 25)
+	oob := AppendGSO(nil, 100)
+
+	_, _, err := WriteMsgUDPAddrPort(ctx, a, payload, oob, b.LocalAddr().(*net.UDPAddr).AddrPort())
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var segs [][]byte
+
+	buf := make([]byte, 1000)
+	oob = make([]byte, 64)
+
+	for len(segs) < 3 {
+		n, oobn, _, _, err := ReadMsgUDPAddrPort(ctx, b, buf, oob)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+
+		size, ok := ParseGRO(oob[:oobn])
+		if ok && groErr != nil {
+			t.Errorf("unexpected GRO message")
+		}
+
+		segs = SplitSegments(segs, append([]byte{}, buf[:n]...), size)
+	}
+
+	for i, exp := range []int{100, 100, 50} {
+		if len(segs[i]) != exp || !bytes.Equal(segs[i], payload[i*100:i*100+exp]) {
+			t.Errorf("segment %d: %q", i, segs[i])
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package hnet
+
+import (
+	"errors"
+	"syscall"
+)
+
+// AppendGSO returns oob unchanged as GSO is only supported on Linux.
+func AppendGSO(oob []byte, size int) []byte {
+	return oob
+}
+
+// ParseGRO always returns false as GRO is only supported on Linux.
+func ParseGRO(oob []byte) (size int, ok bool) {
+	return 0, false
+}
+
+// SupportsGSO always returns false as GSO is only supported on Linux.
+func SupportsGSO(c syscall.Conn) bool {
+	return false
+}
+
+// EnableGRO returns errors.ErrUnsupported as GRO is only supported on Linux.
+func EnableGRO(c syscall.Conn) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+package hnet
+
+import "testing"
+
+func TestSplitSegments(t *testing.T) {
+	segs := SplitSegments(nil, []byte("aabbc"), 2)
+	if len(segs) != 3 || string(segs[2]) != "c" {
+		t.Errorf("segments: %q", segs)
+	}
+
+	segs = SplitSegments(segs[:0], []byte("aabbc"), 0)
+	if len(segs) != 1 {
+		t.Errorf("segments: %q", segs)
+	}
+}
@@ -0,0 +1,151 @@
+// Package hnettest provides in-memory connections, listeners and packet connections
+// with real sockets deadline semantics and fault injection.
+package hnettest
+
+import (
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Addr is an in-memory address.
+	Addr string
+
+	// Faults is injected into connection operations.
+	Faults struct {
+		// Latency is the delay before written data becomes readable by the peer.
+		Latency time.Duration
+
+		// MaxRead limits the number of bytes returned by a single Read.
+		MaxRead int
+
+		// MaxWrite limits the number of bytes accepted by a single Write.
+		// The rest is not written and io.ErrShortWrite is returned.
+		MaxWrite int
+
+		// ReadError and WriteError are called before each operation.
+		// Non-nil error is returned wrapped into *net.OpError.
+		ReadError  func() error
+		WriteError func() error
+	}
+
+	// deadline is closed when the time set has come.
+	deadline struct {
+		mu    sync.Mutex
+		timer *time.Timer
+		c     chan struct{}
+	}
+)
+
+// Network is "mem".
+const Network = "mem"
+
+var addrSeq atomic.Int64
+
+func (a Addr) Network() string { return Network }
+func (a Addr) String() string  { return string(a) }
+
+func newDeadline() *deadline {
+	return &deadline{c: make(chan struct{})}
+}
+
+func (d *deadline) set(t time.Time) {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.c // wait for the timer to close it
+	}
+
+	d.timer = nil
+
+	closed := isClosed(d.c)
+
+	if t.IsZero() {
+		if closed {
+			d.c = make(chan struct{})
+		}
+
+		return
+	}
+
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.c = make(chan struct{})
+		}
+
+		c := d.c
+		d.timer = time.AfterFunc(dur, func() { close(c) })
+
+		return
+	}
+
+	if !closed {
+		close(d.c)
+	}
+}
+
+func (d *deadline) wait() <-chan struct{} {
+	defer d.mu.Unlock()
+	d.mu.Lock()
+
+	return d.c
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+// check returns an error if the connection is closed, the deadline has passed
+// or an error is injected.
+func check(done <-chan struct{}, dl *deadline, inject func() error) error {
+	if isClosed(done) {
+		return net.ErrClosed
+	}
+
+	if isClosed(dl.wait()) {
+		return os.ErrDeadlineExceeded
+	}
+
+	if inject != nil {
+		return inject()
+	}
+
+	return nil
+}
+
+// wait waits for wake or until at if set.
+// It returns an error if the deadline has passed or the connection is closed.
+func wait(wake <-chan struct{}, at time.Time, dl *deadline, done <-chan struct{}) error {
+	var timer <-chan time.Time
+
+	if !at.IsZero() {
+		t := time.NewTimer(time.Until(at))
+		defer t.Stop()
+
+		timer = t.C
+	}
+
+	select {
+	case <-wake:
+	case <-timer:
+	case <-dl.wait():
+		return os.ErrDeadlineExceeded
+	case <-done:
+		return net.ErrClosed
+	}
+
+	return nil
+}
+
+func opError(op string, local, remote net.Addr, err error) error {
+	return &net.OpError{Op: op, Net: Network, Source: local, Addr: remote, Err: err}
+}
@@ -0,0 +1,148 @@
+package hnettest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// Listener is an in-memory listener.
+	// Dial and DialContext create a Pipe and pass its second end to Accept.
+	Listener struct {
+		// Options are used for new connections.
+		// Faults A are of the dialed conn, B are of the accepted one.
+		// Must be set before the first Dial.
+		Options PipeOptions
+
+		addr Addr
+
+		conns chan *Conn
+		dl    *deadline
+
+		done chan struct{}
+		once sync.Once
+	}
+
+	refusedError struct{}
+)
+
+// ErrRefused is returned by Dial if the listener is closed.
+// It matches the system connection refused errno with errors.Is,
+// so hnet.IsRefused reports it as a real refused dial.
+var ErrRefused error = refusedError{}
+
+// Backlog is the number of connections Dial may create before they are accepted.
+const Backlog = 16
+
+// NewListener creates a listener with the address name.
+func NewListener(name string) *Listener {
+	return &Listener{
+		addr:  Addr(name),
+		conns: make(chan *Conn, Backlog),
+		dl:    newDeadline(),
+		done:  make(chan struct{}),
+	}
+}
+
+// Accept waits for the next connection.
+// It respects the deadline set by SetDeadline.
+func (l *Listener) Accept() (net.Conn, error) {
+	err := check(l.done, l.dl, nil)
+	if err != nil {
+		return nil, l.opError("accept", err)
+	}
+
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.dl.wait():
+		return nil, l.opError("accept", os.ErrDeadlineExceeded)
+	case <-l.done:
+		return nil, l.opError("accept", net.ErrClosed)
+	}
+}
+
+// Dial connects to the listener.
+func (l *Listener) Dial() (net.Conn, error) {
+	return l.DialContext(context.Background(), Network, string(l.addr))
+}
+
+// DialContext connects to the listener.
+// network and addr are ignored, it has the net.Dialer.DialContext signature
+// to be used as a custom dialer.
+// It blocks if the Backlog is full until the connection is accepted or ctx is canceled.
+func (l *Listener) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	local := Addr(fmt.Sprintf("%v:%d", l.addr, addrSeq.Add(1)))
+
+	c, s := newPipe(local, l.addr, l.Options)
+
+	if isClosed(l.done) {
+		return nil, opError("dial", local, l.addr, ErrRefused)
+	}
+
+	select {
+	case l.conns <- s:
+	case <-ctx.Done():
+		return nil, opError("dial", local, l.addr, ctx.Err())
+	case <-l.done:
+		return nil, opError("dial", local, l.addr, ErrRefused)
+	}
+
+	// Close could miss it
+	if isClosed(l.done) {
+		_ = s.Close()
+		_ = c.Close()
+
+		return nil, opError("dial", local, l.addr, ErrRefused)
+	}
+
+	return c, nil
+}
+
+// Close closes the listener and all the connections not accepted yet.
+func (l *Listener) Close() error {
+	err := l.opError("close", net.ErrClosed)
+
+	l.once.Do(func() {
+		close(l.done)
+
+		for {
+			select {
+			case c := <-l.conns:
+				_ = c.Close()
+			default:
+				err = nil
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+func (l *Listener) Addr() net.Addr { return l.addr }
+
+// SetDeadline sets the Accept deadline.
+func (l *Listener) SetDeadline(t time.Time) error {
+	if isClosed(l.done) {
+		return l.opError("set", net.ErrClosed)
+	}
+
+	l.dl.set(t)
+
+	return nil
+}
+
+func (l *Listener) opError(op string, err error) error {
+	return opError(op, nil, l.addr, err)
+}
+
+func (refusedError) Error() string { return "connection refused" }
+
+func (refusedError) Is(target error) bool {
+	return refusedErrno != nil && target == refusedErrno
+}
@@ -0,0 +1,88 @@
+package hnettest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"nikand.dev/go/hacked/hnet"
+)
+
+func TestListener(t *testing.T) {
+	l := NewListener("server")
+	defer l.Close()
+
+	errc := make(chan error, 1)
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+
+		defer c.Close()
+
+		_, err = io.Copy(c, c)
+		errc <- err
+	}()
+
+	c, err := l.Dial()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	if c.RemoteAddr().String() != "server" || c.LocalAddr().Network() != Network {
+		t.Errorf("addrs: %v -> %v", c.LocalAddr(), c.RemoteAddr())
+	}
+
+	_, _ = c.Write([]byte("echo"))
+	_ = c.(*Conn).CloseWrite()
+
+	data, err := io.ReadAll(c)
+	if string(data) != "echo" || err != nil {
+		t.Errorf("read: %q %v", data, err)
+	}
+
+	if err := <-errc; err != nil {
+		t.Errorf("server: %v", err)
+	}
+}
+
+func TestListenerDeadline(t *testing.T) {
+	l := NewListener("server")
+	defer l.Close()
+
+	_ = l.SetDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, err := l.Accept()
+	if !os.IsTimeout(err) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	_ = l.SetDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err = hnet.Accept(ctx, l)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	_ = l.Close()
+
+	_, err = l.Dial()
+	if !errors.Is(err, ErrRefused) || refusedErrno != nil && !hnet.IsRefused(err) {
+		t.Errorf("dial closed: %v", err)
+	}
+
+	_, err = l.Accept()
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("accept closed: %v", err)
+	}
+}
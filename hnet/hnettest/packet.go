@@ -0,0 +1,281 @@
+package hnettest
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+type (
+	// PacketConn is one end of an in-memory packet connection.
+	// Packets written to any address are delivered to the peer.
+	// Like UDP, packets may be lost, reordered, and are dropped if the queue is full.
+	PacketConn struct {
+		local, remote Addr
+
+		in, out *queue
+
+		faults Faults
+
+		rdl, wdl *deadline
+
+		done chan struct{}
+		once sync.Once
+	}
+
+	PacketOptions struct {
+		// Loss is the probability of a packet to be dropped.
+		Loss float64
+
+		// Reorder is the probability of a packet to be put
+		// before the ones waiting in the queue.
+		Reorder float64
+
+		// QueueLen is the number of packets waiting to be read
+		// before new ones are dropped. Default is DefaultQueueLen.
+		QueueLen int
+
+		// Seed makes losses and reorders reproducible.
+		Seed int64
+
+		// A and B are the faults of the first and the second conn respectively.
+		// MaxRead truncates packets, MaxWrite is ignored.
+		A, B Faults
+	}
+
+	queue struct {
+		mu     sync.Mutex
+		pkts   []packet
+		closed bool
+		wake   chan struct{}
+
+		rnd     *rand.Rand
+		loss    float64
+		reorder float64
+		max     int
+	}
+
+	packet struct {
+		b    []byte
+		from Addr
+		at   time.Time
+	}
+)
+
+// DefaultQueueLen is the default PacketOptions.QueueLen.
+const DefaultQueueLen = 128
+
+// PacketPipe returns a connected pair of in-memory packet connections.
+func PacketPipe(opts PacketOptions) (a, b *PacketConn) {
+	if opts.QueueLen <= 0 {
+		opts.QueueLen = DefaultQueueLen
+	}
+
+	n := addrSeq.Add(2)
+
+	aaddr := Addr(fmt.Sprintf("packet:%d", n-1))
+	baddr := Addr(fmt.Sprintf("packet:%d", n))
+
+	ab := newQueue(opts, opts.Seed)
+	ba := newQueue(opts, opts.Seed+1)
+
+	a = newPacketConn(aaddr, baddr, ba, ab, opts.A)
+	b = newPacketConn(baddr, aaddr, ab, ba, opts.B)
+
+	return a, b
+}
+
+func newPacketConn(local, remote Addr, in, out *queue, f Faults) *PacketConn {
+	return &PacketConn{
+		local:  local,
+		remote: remote,
+		in:     in,
+		out:    out,
+		faults: f,
+		rdl:    newDeadline(),
+		wdl:    newDeadline(),
+		done:   make(chan struct{}),
+	}
+}
+
+// ReadFrom reads the next packet.
+// If p is too short the packet is truncated.
+func (c *PacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	err = check(c.done, c.rdl, c.faults.ReadError)
+	if err != nil {
+		return 0, nil, c.opError("read", err)
+	}
+
+	if c.faults.MaxRead > 0 && len(p) > c.faults.MaxRead {
+		p = p[:c.faults.MaxRead]
+	}
+
+	for {
+		n, from, wake, at, ok := c.in.read(p)
+		if ok {
+			return n, from, nil
+		}
+
+		err = wait(wake, at, c.rdl, c.done)
+		if err != nil {
+			return 0, nil, c.opError("read", err)
+		}
+	}
+}
+
+// WriteTo sends the packet to the peer, addr is ignored.
+// It never blocks.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	err = check(c.done, c.wdl, c.faults.WriteError)
+	if err != nil {
+		return 0, c.opError("write", err)
+	}
+
+	c.out.write(p, c.local, c.faults.Latency)
+
+	return len(p), nil
+}
+
+func (c *PacketConn) Close() error {
+	err := c.opError("close", net.ErrClosed)
+
+	c.once.Do(func() {
+		close(c.done)
+
+		c.in.close()
+
+		err = nil
+	})
+
+	return err
+}
+
+func (c *PacketConn) LocalAddr() net.Addr { return c.local }
+
+// RemoteAddr returns the peer address.
+func (c *PacketConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.rdl.set(t)
+	c.wdl.set(t)
+
+	return nil
+}
+
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.rdl.set(t)
+
+	return nil
+}
+
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.wdl.set(t)
+
+	return nil
+}
+
+func (c *PacketConn) opError(op string, err error) error {
+	return opError(op, c.local, c.remote, err)
+}
+
+func newQueue(opts PacketOptions, seed int64) *queue {
+	return &queue{
+		wake:    make(chan struct{}),
+		rnd:     rand.New(rand.NewSource(seed)), //nolint:gosec
+		loss:    opts.Loss,
+		reorder: opts.Reorder,
+		max:     opts.QueueLen,
+	}
+}
+
+// read reads the first packet if it's ready.
+// Otherwise it returns the channel to wait for changes on
+// and the time the first packet becomes ready if there is one.
+func (q *queue) read(p []byte) (n int, from net.Addr, wake <-chan struct{}, at time.Time, ok bool) {
+	defer q.mu.Unlock()
+	q.mu.Lock()
+
+	if len(q.pkts) == 0 {
+		return 0, nil, q.wake, time.Time{}, false
+	}
+
+	pk := q.pkts[0]
+
+	if pk.at.After(time.Now()) {
+		return 0, nil, q.wake, pk.at, false
+	}
+
+	q.pkts[0] = packet{}
+	q.pkts = q.pkts[1:]
+
+	n = copy(p, pk.b)
+
+	return n, pk.from, nil, time.Time{}, true
+}
+
+func (q *queue) write(p []byte, from Addr, latency time.Duration) {
+	defer q.mu.Unlock()
+	q.mu.Lock()
+
+	// random numbers are drawn for each packet to keep the sequence reproducible
+	lost := q.rnd.Float64() < q.loss
+	reorder := q.rnd.Float64() < q.reorder
+
+	if q.closed || lost || len(q.pkts) >= q.max {
+		return
+	}
+
+	pk := packet{
+		b:    append([]byte{}, p...),
+		from: from,
+	}
+
+	if latency != 0 {
+		pk.at = time.Now().Add(latency)
+	}
+
+	if !reorder || len(q.pkts) == 0 {
+		q.pkts = append(q.pkts, pk)
+		q.signal()
+
+		return
+	}
+
+	i := q.rnd.Intn(len(q.pkts))
+
+	pk.at = q.pkts[i].at
+
+	q.pkts = append(q.pkts, packet{})
+	copy(q.pkts[i+1:], q.pkts[i:])
+	q.pkts[i] = pk
+
+	q.signal()
+}
+
+func (q *queue) close() {
+	defer q.mu.Unlock()
+	q.mu.Lock()
+
+	q.closed = true
+	q.pkts = nil
+	q.signal()
+}
+
+func (q *queue) signal() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
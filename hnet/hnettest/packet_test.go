@@ -0,0 +1,82 @@
+package hnettest
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPacketPipe(t *testing.T) {
+	a, b := PacketPipe(PacketOptions{})
+	defer a.Close()
+	defer b.Close()
+
+	_, err := a.WriteTo([]byte("ping"), nil)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buf := make([]byte, 2)
+
+	n, addr, err := b.ReadFrom(buf)
+	if n != 2 || addr != a.LocalAddr() || err != nil {
+		t.Errorf("read truncated: %q %v %v", buf[:n], addr, err)
+	}
+
+	_ = b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, _, err = b.ReadFrom(buf)
+	if !os.IsTimeout(err) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestPacketPipeLossReorder(t *testing.T) {
+	run := func() []byte {
+		a, b := PacketPipe(PacketOptions{
+			Loss:    0.3,
+			Reorder: 0.3,
+			Seed:    1,
+		})
+		defer a.Close()
+		defer b.Close()
+
+		for i := 0; i < 100; i++ {
+			_, _ = a.WriteTo([]byte{byte(i)}, nil)
+		}
+
+		_ = b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+		var got []byte
+		buf := make([]byte, 1)
+
+		for {
+			n, _, err := b.ReadFrom(buf)
+			if err != nil {
+				return got
+			}
+
+			got = append(got, buf[:n]...)
+		}
+	}
+
+	got := run()
+
+	if len(got) < 50 || len(got) > 90 {
+		t.Errorf("received %d of 100", len(got))
+	}
+
+	reordered := false
+
+	for i := 1; i < len(got); i++ {
+		reordered = reordered || got[i] < got[i-1]
+	}
+
+	if !reordered {
+		t.Errorf("not reordered: %v", got)
+	}
+
+	if again := run(); string(again) != string(got) {
+		t.Errorf("not reproducible:\n%v\n%v", got, again)
+	}
+}
@@ -0,0 +1,331 @@
+package hnettest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+type (
+	// Conn is one end of an in-memory buffered stream connection.
+	// Unlike net.Pipe, writes are buffered, deadlines behave as on real sockets,
+	// and CloseWrite is supported.
+	// Errors are *net.OpError wrapping os.ErrDeadlineExceeded, net.ErrClosed
+	// or io.ErrClosedPipe if the peer is closed.
+	Conn struct {
+		local, remote Addr
+
+		in, out *stream
+
+		faults Faults
+
+		rdl, wdl *deadline
+
+		done chan struct{}
+		once sync.Once
+	}
+
+	PipeOptions struct {
+		// BufSize is the buffer size in each direction.
+		// Write blocks if the buffer is full. Default is DefaultBufSize.
+		BufSize int
+
+		// A and B are the faults of the first and the second conn respectively.
+		A, B Faults
+	}
+
+	stream struct {
+		mu     sync.Mutex
+		chunks []chunk
+		size   int
+		cap    int
+		eof    bool // write side is closed
+		closed bool // read side is closed
+		wake   chan struct{}
+	}
+
+	chunk struct {
+		b  []byte
+		at time.Time
+	}
+)
+
+// DefaultBufSize is the default PipeOptions.BufSize.
+const DefaultBufSize = 64 << 10
+
+// Pipe returns a connected pair of in-memory connections with default options.
+func Pipe() (*Conn, *Conn) {
+	return NewPipe(PipeOptions{})
+}
+
+// NewPipe returns a connected pair of in-memory connections.
+func NewPipe(opts PipeOptions) (a, b *Conn) {
+	n := addrSeq.Add(2)
+
+	return newPipe(Addr(fmt.Sprintf("pipe:%d", n-1)), Addr(fmt.Sprintf("pipe:%d", n)), opts)
+}
+
+func newPipe(aaddr, baddr Addr, opts PipeOptions) (a, b *Conn) {
+	if opts.BufSize <= 0 {
+		opts.BufSize = DefaultBufSize
+	}
+
+	ab := newStream(opts.BufSize)
+	ba := newStream(opts.BufSize)
+
+	a = newConn(aaddr, baddr, ba, ab, opts.A)
+	b = newConn(baddr, aaddr, ab, ba, opts.B)
+
+	return a, b
+}
+
+func newConn(local, remote Addr, in, out *stream, f Faults) *Conn {
+	return &Conn{
+		local:  local,
+		remote: remote,
+		in:     in,
+		out:    out,
+		faults: f,
+		rdl:    newDeadline(),
+		wdl:    newDeadline(),
+		done:   make(chan struct{}),
+	}
+}
+
+func (c *Conn) Read(p []byte) (n int, err error) {
+	err = check(c.done, c.rdl, c.faults.ReadError)
+	if err != nil {
+		return 0, c.opError("read", err)
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if c.faults.MaxRead > 0 && len(p) > c.faults.MaxRead {
+		p = p[:c.faults.MaxRead]
+	}
+
+	for {
+		n, wake, at, err := c.in.read(p)
+		if err == io.EOF {
+			return 0, err
+		}
+		if n != 0 {
+			return n, nil
+		}
+
+		err = wait(wake, at, c.rdl, c.done)
+		if err != nil {
+			return 0, c.opError("read", err)
+		}
+	}
+}
+
+func (c *Conn) Write(p []byte) (n int, err error) {
+	err = check(c.done, c.wdl, c.faults.WriteError)
+	if err != nil {
+		return 0, c.opError("write", err)
+	}
+
+	q := p
+
+	if c.faults.MaxWrite > 0 && len(q) > c.faults.MaxWrite {
+		q = q[:c.faults.MaxWrite]
+	}
+
+	for n < len(q) {
+		m, wake, err := c.out.write(q[n:], c.faults.Latency)
+		n += m
+
+		if err != nil {
+			return n, c.opError("write", err)
+		}
+		if m != 0 {
+			continue
+		}
+
+		err = wait(wake, time.Time{}, c.wdl, c.done)
+		if err != nil {
+			return n, c.opError("write", err)
+		}
+	}
+
+	if n < len(p) {
+		return n, c.opError("write", io.ErrShortWrite)
+	}
+
+	return n, nil
+}
+
+// CloseWrite closes the writing side, the peer reads io.EOF after the buffered data.
+func (c *Conn) CloseWrite() error {
+	if isClosed(c.done) {
+		return c.opError("close", net.ErrClosed)
+	}
+
+	c.out.closeWrite()
+
+	return nil
+}
+
+func (c *Conn) Close() error {
+	err := c.opError("close", net.ErrClosed)
+
+	c.once.Do(func() {
+		close(c.done)
+
+		c.out.closeWrite()
+		c.in.closeRead()
+
+		err = nil
+	})
+
+	return err
+}
+
+func (c *Conn) LocalAddr() net.Addr  { return c.local }
+func (c *Conn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.rdl.set(t)
+	c.wdl.set(t)
+
+	return nil
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.rdl.set(t)
+
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	if isClosed(c.done) {
+		return c.opError("set", net.ErrClosed)
+	}
+
+	c.wdl.set(t)
+
+	return nil
+}
+
+func (c *Conn) opError(op string, err error) error {
+	return opError(op, c.local, c.remote, err)
+}
+
+func newStream(size int) *stream {
+	return &stream{
+		cap:  size,
+		wake: make(chan struct{}),
+	}
+}
+
+// read reads ready data.
+// If nothing is read it returns the channel to wait for changes on
+// and the time the next chunk becomes ready if there is one.
+func (s *stream) read(p []byte) (n int, wake <-chan struct{}, at time.Time, err error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	now := time.Now()
+
+	for len(s.chunks) != 0 && n < len(p) {
+		ch := &s.chunks[0]
+
+		if ch.at.After(now) {
+			if n == 0 {
+				at = ch.at
+			}
+
+			break
+		}
+
+		m := copy(p[n:], ch.b)
+		n += m
+		ch.b = ch.b[m:]
+
+		if len(ch.b) == 0 {
+			s.chunks[0] = chunk{}
+			s.chunks = s.chunks[1:]
+		}
+	}
+
+	if n != 0 {
+		s.size -= n
+		s.signal()
+
+		return n, nil, time.Time{}, nil
+	}
+
+	if len(s.chunks) == 0 && s.eof {
+		return 0, nil, time.Time{}, io.EOF
+	}
+
+	return 0, s.wake, at, nil
+}
+
+// write writes as much as fits into the buffer.
+// If nothing is written it returns the channel to wait for changes on.
+func (s *stream) write(p []byte, latency time.Duration) (n int, wake <-chan struct{}, err error) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if s.closed || s.eof {
+		return 0, nil, io.ErrClosedPipe
+	}
+
+	n = min(s.cap-s.size, len(p))
+	if n <= 0 {
+		return 0, s.wake, nil
+	}
+
+	var at time.Time
+
+	if latency != 0 {
+		at = time.Now().Add(latency)
+	}
+
+	s.chunks = append(s.chunks, chunk{
+		b:  append([]byte{}, p[:n]...),
+		at: at,
+	})
+
+	s.size += n
+	s.signal()
+
+	return n, nil, nil
+}
+
+func (s *stream) closeWrite() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.eof = true
+	s.signal()
+}
+
+func (s *stream) closeRead() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.closed = true
+	s.chunks = nil
+	s.size = 0
+	s.signal()
+}
+
+func (s *stream) signal() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
@@ -0,0 +1,188 @@
+package hnettest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"nikand.dev/go/hacked/hnet"
+)
+
+func TestPipe(t *testing.T) {
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	// buffered, doesn't block
+	n, err := a.Write([]byte("hello"))
+	if n != 5 || err != nil {
+		t.Fatalf("write: %v %v", n, err)
+	}
+
+	err = a.CloseWrite()
+	if err != nil {
+		t.Fatalf("close write: %v", err)
+	}
+
+	data, err := io.ReadAll(b)
+	if string(data) != "hello" || err != nil {
+		t.Errorf("read all: %q %v", data, err)
+	}
+
+	_, err = a.Write([]byte("x"))
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after close write: %v", err)
+	}
+
+	// other direction still works
+	_, err = b.Write([]byte("back"))
+	if err != nil {
+		t.Fatalf("write back: %v", err)
+	}
+
+	buf := make([]byte, 10)
+
+	n, err = a.Read(buf)
+	if string(buf[:n]) != "back" || err != nil {
+		t.Errorf("read back: %q %v", buf[:n], err)
+	}
+
+	_ = b.Close()
+
+	_, err = a.Read(buf)
+	if err != io.EOF {
+		t.Errorf("read after peer close: %v", err)
+	}
+
+	_, err = a.Write([]byte("x"))
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after peer close: %v", err)
+	}
+
+	_ = a.Close()
+
+	_, err = a.Read(buf)
+	if !errors.Is(err, net.ErrClosed) {
+		t.Errorf("read after close: %v", err)
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "read" || opErr.Net != Network {
+		t.Errorf("op error: %#v", err)
+	}
+}
+
+func TestPipeDeadline(t *testing.T) {
+	a, b := NewPipe(PipeOptions{BufSize: 4})
+	defer a.Close()
+	defer b.Close()
+
+	_ = b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+	buf := make([]byte, 10)
+
+	_, err := b.Read(buf)
+	if !errors.Is(err, os.ErrDeadlineExceeded) || !os.IsTimeout(err) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	// deadline in the past fails even if data is available
+	_, _ = a.Write([]byte("data"))
+
+	_, err = b.Read(buf)
+	if !os.IsTimeout(err) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	_ = b.SetReadDeadline(time.Time{})
+
+	n, err := b.Read(buf)
+	if n != 4 || err != nil {
+		t.Fatalf("read after reset: %v %v", n, err)
+	}
+
+	// buffer is full, write blocks
+	_ = a.SetWriteDeadline(time.Now().Add(10 * time.Millisecond))
+
+	n, err = a.Write([]byte("0123456789"))
+	if n != 4 || !os.IsTimeout(err) {
+		t.Errorf("write: %v %v", n, err)
+	}
+}
+
+func TestPipeFaults(t *testing.T) {
+	injected := errors.New("injected")
+
+	a, b := NewPipe(PipeOptions{
+		A: Faults{
+			Latency:  20 * time.Millisecond,
+			MaxWrite: 3,
+		},
+		B: Faults{
+			MaxRead: 2,
+			ReadError: func() error {
+				return injected
+			},
+		},
+	})
+	defer a.Close()
+	defer b.Close()
+
+	start := time.Now()
+
+	n, err := a.Write([]byte("data"))
+	if n != 3 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("write: %v %v", n, err)
+	}
+
+	_, err = b.Read(make([]byte, 10))
+	if !errors.Is(err, injected) {
+		t.Fatalf("read: %v", err)
+	}
+
+	b.faults.ReadError = nil
+
+	buf := make([]byte, 10)
+
+	n, err = b.Read(buf)
+	if n != 2 || err != nil {
+		t.Fatalf("read: %v %v", n, err)
+	}
+
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("latency: %v", d)
+	}
+}
+
+func TestPipeStoppableConn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c := hnet.NewStoppableConn(ctx, a)
+
+	dl := time.Now().Add(time.Hour)
+	_ = c.SetReadDeadline(dl)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := c.Read(make([]byte, 10))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// the original deadline is restored
+	_, _ = b.Write([]byte("data"))
+
+	n, err := a.Read(make([]byte, 10))
+	if n != 4 || err != nil {
+		t.Errorf("read after cancel: %v %v", n, err)
+	}
+}
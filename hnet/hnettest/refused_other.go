@@ -0,0 +1,5 @@
+//go:build !unix && !windows
+
+package hnettest
+
+var refusedErrno error
@@ -0,0 +1,7 @@
+//go:build unix
+
+package hnettest
+
+import "syscall"
+
+var refusedErrno error = syscall.ECONNREFUSED
@@ -0,0 +1,6 @@
+package hnettest
+
+import "syscall"
+
+// WSAECONNREFUSED is missing in syscall package.
+var refusedErrno error = syscall.Errno(10061)
@@ -0,0 +1,117 @@
+package hnet
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Copy is io.Copy which aborts the whole operation on context cancelation.
+// Cancelation hook is installed once for the whole operation, not for each Read and Write.
+// StoppableConn is unwrapped, so its context hook is also installed once.
+func Copy(ctx context.Context, dst io.Writer, src io.Reader) (n int64, err error) {
+	src, sctx, release := readStopper(ctx, src)
+	defer release()
+
+	dst, dctx, release := writeStopper(ctx, dst)
+	defer release()
+
+	n, err = io.Copy(dst, src)
+	err = fixErrors(ctx, sctx, dctx, "copy", err)
+
+	return n, err
+}
+
+// ReadFull is io.ReadFull which aborts the whole operation on context cancelation.
+func ReadFull(ctx context.Context, r io.Reader, p []byte) (int, error) {
+	return ReadAtLeast(ctx, r, p, len(p))
+}
+
+// ReadAtLeast is io.ReadAtLeast which aborts the whole operation on context cancelation.
+func ReadAtLeast(ctx context.Context, r io.Reader, p []byte, min int) (n int, err error) {
+	r, rctx, release := readStopper(ctx, r)
+	defer release()
+
+	n, err = io.ReadAtLeast(r, p, min)
+	err = fixErrors(ctx, rctx, nil, "read", err)
+
+	return n, err
+}
+
+// WriteAll writes all of p retrying short writes,
+// and aborts the whole operation on context cancelation.
+func WriteAll(ctx context.Context, w io.Writer, p []byte) (n int, err error) {
+	w, wctx, release := writeStopper(ctx, w)
+	defer release()
+
+	for n < len(p) && err == nil {
+		var m int
+
+		m, err = w.Write(p[n:])
+		n += m
+
+		if m == 0 && err == nil {
+			err = io.ErrShortWrite
+		}
+	}
+
+	err = fixErrors(ctx, wctx, nil, "write", err)
+
+	return n, err
+}
+
+// readStopper installs the ctx hook for the whole operation.
+// StoppableConn is unwrapped, the wrapped conn is returned to do the operation on
+// along with the StoppableConn context, which is respected as well.
+func readStopper(ctx context.Context, r io.Reader) (io.Reader, context.Context, func()) {
+	if ctx.Done() == nil {
+		return r, nil, nop
+	}
+
+	if src, sctx, release := unwrapReader(ctx, r); sctx != nil {
+		return src, sctx, release
+	}
+
+	d, ok := r.(interface {
+		SetReadDeadline(time.Time) error
+	})
+	if !ok {
+		return r, nil, nop
+	}
+
+	return r, nil, DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))
+}
+
+func writeStopper(ctx context.Context, w io.Writer) (io.Writer, context.Context, func()) {
+	if ctx.Done() == nil {
+		return w, nil, nop
+	}
+
+	if dst, dctx, release := unwrapWriter(ctx, w); dctx != nil {
+		return dst, dctx, release
+	}
+
+	d, ok := w.(interface {
+		SetWriteDeadline(time.Time) error
+	})
+	if !ok {
+		return w, nil, nop
+	}
+
+	return w, nil, DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))
+}
+
+// fixErrors is FixErrorOp for ctx and the unwrapped conns contexts, which may be nil.
+func fixErrors(ctx, sctx, dctx context.Context, op string, err error) error {
+	err = FixErrorOp(ctx, op, err)
+
+	if sctx != nil {
+		err = FixErrorOp(sctx, op, err)
+	}
+
+	if dctx != nil {
+		err = FixErrorOp(dctx, op, err)
+	}
+
+	return err
+}
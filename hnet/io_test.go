@@ -0,0 +1,84 @@
+package hnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadFull(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_, _ = WriteAll(ctx, b, []byte("head"))
+		time.AfterFunc(10*time.Millisecond, cancel)
+	}()
+
+	buf := make([]byte, 8)
+
+	n, err := ReadFull(ctx, a, buf)
+	if n != 4 || !errors.Is(err, context.Canceled) {
+		t.Errorf("read full: %d %v", n, err)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+
+	go func() {
+		_, _ = WriteAll(context.Background(), b, []byte("data"))
+		_ = b.Close()
+	}()
+
+	var buf bytes.Buffer
+
+	n, err := Copy(context.Background(), &buf, a)
+	if n != 4 || err != nil || buf.String() != "data" {
+		t.Errorf("copy: %d %v %q", n, err, buf.Bytes())
+	}
+}
+
+func TestReadFullStoppableConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	sctx, scancel := context.WithTimeout(context.Background(), time.Hour)
+	defer scancel()
+
+	c := NewStoppableConn(sctx, a)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := ReadFull(ctx, c, make([]byte, 8))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// conn context is respected as well
+	sctx, scancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer scancel()
+
+	c = NewStoppableConn(sctx, a)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = Copy(ctx, io.Discard, c)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
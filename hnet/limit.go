@@ -0,0 +1,252 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"nikand.dev/go/hacked/htime"
+)
+
+type (
+	// LimitedConn is a bandwidth limited connection.
+	// Read and Write are throttled by token buckets
+	// and abort on context cancelation as StoppableConn does.
+	// ReadFrom and WriteTo are intentionally not implemented,
+	// so io.Copy goes through the limited Read and Write.
+	LimitedConn struct {
+		net.Conn // StoppableConn
+
+		ctx context.Context
+
+		read  bucket
+		write bucket
+
+		readBytes  atomic.Int64
+		writeBytes atomic.Int64
+		readWait   atomic.Int64
+		writeWait  atomic.Int64
+	}
+
+	LimitStats struct {
+		Read    int64
+		Written int64
+
+		// ReadWait and WriteWait is the time spent throttled.
+		ReadWait  time.Duration
+		WriteWait time.Duration
+	}
+
+	// bucket is a token bucket refilled at rate bytes per second.
+	// tokens may go negative, which is the debt to wait for.
+	bucket struct {
+		mu     sync.Mutex
+		rate   int64
+		burst  float64
+		tokens float64
+		last   int64 // monotonic
+	}
+)
+
+// NewLimitedConn wraps c to limit its read and write bandwidth in bytes per second.
+// Zero or negative rate means no limit.
+// Each direction allows bursts of 50ms worth of traffic.
+// Connection is wrapped with NewStoppableConn, so ctx cancelation aborts blocked operations
+// as well as waiting for tokens.
+func NewLimitedConn(ctx context.Context, c net.Conn, readBps, writeBps int64) *LimitedConn {
+	l := &LimitedConn{
+		Conn: NewStoppableConn(ctx, c),
+		ctx:  ctx,
+	}
+
+	l.SetLimits(readBps, writeBps)
+
+	return l
+}
+
+// SetLimits changes the limits on the fly.
+// Blocked operations keep the limits they started with.
+func (c *LimitedConn) SetLimits(readBps, writeBps int64) {
+	c.read.setRate(readBps)
+	c.write.setRate(writeBps)
+}
+
+// Stats returns live counters.
+func (c *LimitedConn) Stats() LimitStats {
+	return LimitStats{
+		Read:      c.readBytes.Load(),
+		Written:   c.writeBytes.Load(),
+		ReadWait:  time.Duration(c.readWait.Load()),
+		WriteWait: time.Duration(c.writeWait.Load()),
+	}
+}
+
+func (c *LimitedConn) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return c.Conn.Read(p)
+	}
+
+	// we don't know how much will be read, so wait for the first byte only
+	size, wait := c.read.reserve(1, len(p))
+
+	err = c.wait(wait, "read", readDeadline(c.Conn), &c.readWait)
+	if err != nil {
+		c.read.take(-1)
+		return 0, err
+	}
+
+	n, err = c.Conn.Read(p[:size])
+
+	c.read.take(int64(n - 1))
+	c.readBytes.Add(int64(n))
+
+	return n, err
+}
+
+func (c *LimitedConn) Write(p []byte) (n int, err error) {
+	for n < len(p) {
+		size, wait := c.write.reserve(len(p)-n, len(p)-n)
+
+		err = c.wait(wait, "write", writeDeadline(c.Conn), &c.writeWait)
+		if err != nil {
+			c.write.take(-int64(size))
+			return n, err
+		}
+
+		var m int
+
+		m, err = c.Conn.Write(p[n : n+size])
+		n += m
+
+		c.write.take(int64(m - size))
+		c.writeBytes.Add(int64(m))
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// CloseWrite shuts down the writing side of the connection.
+// errors.ErrUnsupported is returned if the wrapped connection doesn't support it.
+func (c *LimitedConn) CloseWrite() error {
+	return closeWrite(c.Conn)
+}
+
+// CloseRead shuts down the reading side of the connection.
+func (c *LimitedConn) CloseRead() error {
+	return closeRead(c.Conn)
+}
+
+func (c *LimitedConn) SyscallConn() (syscall.RawConn, error) {
+	return syscallConn(c.Conn)
+}
+
+// wait sleeps for d respecting the context and the connection deadline.
+func (c *LimitedConn) wait(d time.Duration, op string, dl func() time.Time, total *atomic.Int64) error {
+	if d <= 0 {
+		return nil
+	}
+
+	var timeout bool
+
+	if t := deadlineOf(dl); !t.IsZero() {
+		if left := time.Until(t); left < d {
+			d, timeout = left, true
+		}
+	}
+
+	start := htime.Monotonic()
+	err := sleep(c.ctx, d)
+	total.Add(htime.Monotonic() - start)
+
+	if err != nil {
+		return FixErrorOp(c.ctx, op, os.ErrDeadlineExceeded)
+	}
+
+	if timeout {
+		err := &net.OpError{Op: op, Source: c.LocalAddr(), Addr: c.RemoteAddr(), Err: os.ErrDeadlineExceeded}
+
+		if err.Addr != nil {
+			err.Net = err.Addr.Network()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (b *bucket) setRate(rate int64) {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+
+	first := b.last == 0
+
+	b.refill()
+
+	b.rate = rate
+	b.burst = max(float64(rate)/20, 1)
+	b.tokens = min(b.tokens, b.burst)
+
+	if first {
+		b.tokens = b.burst
+	}
+}
+
+// reserve takes need tokens and returns the operation size up to n
+// and the time to wait before doing it.
+// Size is capped to the burst, need is capped to size.
+func (b *bucket) reserve(need, n int) (size int, wait time.Duration) {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+
+	if b.rate <= 0 {
+		return n, 0
+	}
+
+	b.refill()
+
+	size = int(min(float64(n), b.burst))
+	need = min(need, size)
+
+	b.tokens -= float64(need)
+
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / float64(b.rate) * float64(time.Second))
+	}
+
+	return size, wait
+}
+
+// take accounts n more bytes, negative n returns tokens.
+func (b *bucket) take(n int64) {
+	defer b.mu.Unlock()
+	b.mu.Lock()
+
+	if b.rate <= 0 {
+		return
+	}
+
+	b.refill()
+
+	b.tokens = min(b.tokens-float64(n), b.burst)
+}
+
+func (b *bucket) refill() {
+	now := htime.Monotonic()
+	elapsed := time.Duration(now - b.last)
+	b.last = now
+
+	if b.rate <= 0 {
+		return
+	}
+
+	b.tokens = min(b.tokens+elapsed.Seconds()*float64(b.rate), b.burst)
+}
@@ -0,0 +1,109 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLimitedConn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	const rate, size = 20000, 4000
+
+	c := NewLimitedConn(ctx, a, 0, rate)
+
+	go func() {
+		_, _ = io.Copy(io.Discard, b)
+	}()
+
+	start := time.Now()
+
+	n, err := c.Write(make([]byte, size))
+	if n != size || err != nil {
+		t.Fatalf("write: %v %v", n, err)
+	}
+
+	// burst is 50ms, the rest is throttled
+	if d, exp := time.Since(start), 150*time.Millisecond; d < exp {
+		t.Errorf("write took %v, want at least %v", d, exp)
+	}
+
+	st := c.Stats()
+	if st.Written != size || st.WriteWait == 0 || st.Read != 0 {
+		t.Errorf("stats: %+v", st)
+	}
+}
+
+func TestLimitedConnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	c := NewLimitedConn(ctx, a, 10, 10)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	n, err := c.Write(make([]byte, 100))
+	if n != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("write: %v %v", n, err)
+	}
+}
+
+func TestLimitedConnDeadline(t *testing.T) {
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	c := NewLimitedConn(context.Background(), a, 1, 1)
+
+	_, err := b.Write([]byte("data"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buf := make([]byte, 10)
+
+	n, err := c.Read(buf)
+	if n != 1 || err != nil {
+		t.Fatalf("first read: %v %v", n, err)
+	}
+
+	_ = c.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+
+	_, err = c.Read(buf)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected deadline, got %v", err)
+	}
+}
+
+func TestLimitedConnCloseWrite(t *testing.T) {
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	c := NewLimitedConn(context.Background(), a, 0, 0)
+
+	_, _ = c.Write([]byte("data"))
+
+	err := CloseWriter(c)
+	if err != nil {
+		t.Fatalf("close write: %v", err)
+	}
+
+	data, err := io.ReadAll(b)
+	if string(data) != "data" || err != nil {
+		t.Errorf("read: %q %v", data, err)
+	}
+}
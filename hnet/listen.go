@@ -2,6 +2,7 @@ package hnet
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net"
 	"net/netip"
@@ -13,11 +14,51 @@ type (
 	StoppableListener struct {
 		context.Context
 		net.Listener
+
+		dl *deadlines // accept deadline is tracked as read
+	}
+
+	// stoppableDeadlineListener is a StoppableListener
+	// which wrapped listener has SetDeadline.
+	stoppableDeadlineListener struct {
+		StoppableListener
 	}
 
 	StoppableConn struct {
 		context.Context
 		net.Conn
+
+		dl *deadlines
+	}
+
+	stopper struct {
+		dead    func(time.Time) error
+		prev    time.Time
+		stop    func() bool
+		stopOwn func() bool
+
+		set bool // context deadline was applied
+
+		mu     sync.Mutex
+		done   bool
+		killed bool
+	}
+
+	// ReadDeadliner is implemented by connections which report
+	// the read deadline set by the user.
+	// It's restored after the context deadline was applied.
+	ReadDeadliner interface {
+		ReadDeadline() time.Time
+	}
+
+	// WriteDeadliner is the same as ReadDeadliner but for writes.
+	WriteDeadliner interface {
+		WriteDeadline() time.Time
+	}
+
+	// AcceptDeadliner is the same as ReadDeadliner but for listeners.
+	AcceptDeadliner interface {
+		AcceptDeadline() time.Time
 	}
 
 	ReaderFrom interface {
@@ -42,7 +83,20 @@ type (
 )
 
 // Accept wraps l.Accept but aborts the operation on context cancelation.
+// Context deadline is applied if l reports the deadline set by the caller (AcceptDeadliner).
+// StoppableListener is unwrapped, so both its and ctx contexts are respected.
 func Accept(ctx context.Context, l net.Listener) (net.Conn, error) {
+	switch sl := l.(type) {
+	case StoppableListener:
+		return accept(ctx, sl.Context, sl.Listener, sl.AcceptDeadline)
+	case stoppableDeadlineListener:
+		return accept(ctx, sl.Context, sl.Listener, sl.AcceptDeadline)
+	}
+
+	return accept(ctx, nil, l, acceptDeadline(l))
+}
+
+func accept(ctx, own context.Context, l net.Listener, prev func() time.Time) (net.Conn, error) {
 	d, ok := l.(interface {
 		SetDeadline(time.Time) error
 	})
@@ -50,14 +104,18 @@ func Accept(ctx context.Context, l net.Listener) (net.Conn, error) {
 		return l.Accept()
 	}
 
-	defer Stopper(ctx, d.SetDeadline)()
+	defer deadlineStopper(ctx, own, d.SetDeadline, prev)()
 
 	c, err := l.Accept()
 	if c != nil {
 		return c, err
 	}
 
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "accept", err)
+
+	if own != nil {
+		err = FixErrorOp(own, "accept", err)
+	}
 
 	return nil, err
 }
@@ -67,14 +125,14 @@ func Read(ctx context.Context, r io.Reader, p []byte) (int, error) {
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.Read(p)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, err := r.Read(p)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return n, err
 }
@@ -84,14 +142,14 @@ func ReadFrom(ctx context.Context, r ReaderFrom, p []byte) (int, net.Addr, error
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.ReadFrom(p)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, addr, err := r.ReadFrom(p)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return n, addr, err
 }
@@ -101,14 +159,14 @@ func ReadFromUDP(ctx context.Context, r ReaderFromUDP, p []byte) (int, *net.UDPA
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.ReadFromUDP(p)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, addr, err := r.ReadFromUDP(p)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return n, addr, err
 }
@@ -118,14 +176,14 @@ func ReadFromUDPAddrPort(ctx context.Context, r ReaderFromUDPAddrPort, p []byte)
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.ReadFromUDPAddrPort(p)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, addr, err := r.ReadFromUDPAddrPort(p)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return n, addr, err
 }
@@ -135,14 +193,14 @@ func ReadMsgUDP(ctx context.Context, r ReaderMsgUDP, p, oob []byte) (n, oobn, fl
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.ReadMsgUDP(p, oob)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, oobn, flags, addr, err = r.ReadMsgUDP(p, oob)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return
 }
@@ -152,98 +210,279 @@ func ReadMsgUDPAddrPort(ctx context.Context, r ReaderMsgUDPAddrPort, p, oob []by
 	d, ok := r.(interface {
 		SetReadDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return r.ReadMsgUDPAddrPort(p, oob)
 	}
 
-	defer Stopper(ctx, d.SetReadDeadline)()
+	defer DeadlineStopper(ctx, d.SetReadDeadline, readDeadline(r))()
 
 	n, oobn, flags, addr, err = r.ReadMsgUDPAddrPort(p, oob)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "read", err)
 
 	return
 }
 
 // NewStoppableListener wraps listener to have the same interface,
 // but returned listener aborts on context cancellation.
+// The result has SetDeadline only if l has it.
+// The deadline set by it is tracked and restored after the context deadline or cancellation.
 func NewStoppableListener(ctx context.Context, l net.Listener) net.Listener {
-	return StoppableListener{
+	sl := StoppableListener{
 		Context:  ctx,
 		Listener: l,
+		dl:       &deadlines{},
 	}
+
+	if _, ok := l.(interface {
+		SetDeadline(time.Time) error
+	}); ok {
+		return stoppableDeadlineListener{sl}
+	}
+
+	return sl
 }
 
 func (l StoppableListener) Accept() (net.Conn, error) {
-	return Accept(l.Context, l.Listener)
+	return accept(l.Context, nil, l.Listener, l.AcceptDeadline)
 }
 
-// NewStoppableConn wraps connection to have the same interface,
-// but returned connection aborts on context cancellation.
-func NewStoppableConn(ctx context.Context, c net.Conn) net.Conn {
-	return StoppableConn{
-		Context: ctx,
-		Conn:    c,
+// AcceptDeadline returns the last accept deadline set by the user.
+func (l StoppableListener) AcceptDeadline() time.Time {
+	if l.dl == nil {
+		return deadlineOf(acceptDeadline(l.Listener))
 	}
+
+	return l.dl.readDeadline()
+}
+
+// SetDeadline sets the wrapped listener deadline and remembers it.
+func (l stoppableDeadlineListener) SetDeadline(t time.Time) error {
+	l.dl.setRead(t)
+
+	return l.Listener.(interface {
+		SetDeadline(time.Time) error
+	}).SetDeadline(t)
 }
 
 func (c StoppableConn) Read(p []byte) (n int, err error) {
-	defer Stopper(c.Context, c.Conn.SetReadDeadline)()
+	if c.Context.Done() == nil {
+		return c.Conn.Read(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.Conn.SetReadDeadline, c.ReadDeadline)()
 
 	n, err = c.Conn.Read(p)
-	err = FixError(c.Context, err)
+	err = FixErrorOp(c.Context, "read", err)
 
 	return
 }
 
 func (c StoppableConn) Write(p []byte) (n int, err error) {
-	defer Stopper(c.Context, c.Conn.SetWriteDeadline)()
+	if c.Context.Done() == nil {
+		return c.Conn.Write(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.Conn.SetWriteDeadline, c.WriteDeadline)()
 
 	n, err = c.Conn.Write(p)
-	err = FixError(c.Context, err)
+	err = FixErrorOp(c.Context, "write", err)
 
 	return
 }
 
+// SetDeadline sets the underlying connection deadlines and remembers them,
+// so they are restored after context deadline or cancellation.
+func (c StoppableConn) SetDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setRead(t)
+		c.dl.setWrite(t)
+	}
+
+	return c.Conn.SetDeadline(t)
+}
+
+func (c StoppableConn) SetReadDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setRead(t)
+	}
+
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c StoppableConn) SetWriteDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setWrite(t)
+	}
+
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// ReadDeadline returns the last read deadline set by the user.
+func (c StoppableConn) ReadDeadline() time.Time {
+	if c.dl == nil {
+		return deadlineOf(readDeadline(c.Conn))
+	}
+
+	return c.dl.readDeadline()
+}
+
+// WriteDeadline returns the last write deadline set by the user.
+func (c StoppableConn) WriteDeadline() time.Time {
+	if c.dl == nil {
+		return deadlineOf(writeDeadline(c.Conn))
+	}
+
+	return c.dl.writeDeadline()
+}
+
 // Stopper is a helper function which calls dead if context is canceled.
-// Returned function must be called with defer to release goroutine used internally.
+// Context deadline is handled the same way when it passes.
+// Stopper doesn't know the deadline set by the caller,
+// so it's reset to zero after the operation if it was aborted.
+// Use DeadlineStopper or the tracking wrappers (NewStoppableConn, NewStoppableListener)
+// to keep it.
+// Returned function must be called with defer to release the context.AfterFunc hook.
+// Nothing is installed for contexts which are never canceled (context.Background).
 func Stopper(ctx context.Context, dead func(time.Time) error) func() {
-	donec := make(chan struct{})
+	return DeadlineStopper(ctx, dead, nil)
+}
 
-	var mu sync.Mutex
-	var killed bool
+// DeadlineStopper is the same as Stopper but it takes into account
+// the deadline set on the connection by the caller.
+// prev returns that deadline, nil means it's unknown and it works as Stopper.
+// It's called once before the operation, so dead may be
+// the method updating the value returned by prev.
+// The earliest of the context and prev deadlines is applied,
+// and prev deadline is restored when returned function is called.
+//
+// Standard library connections and listeners don't report their deadlines,
+// wrap them with NewStoppableConn or NewStoppableListener to track them.
+func DeadlineStopper(ctx context.Context, dead func(time.Time) error, prev func() time.Time) func() {
+	return deadlineStopper(ctx, nil, dead, prev)
+}
 
-	go func() {
-		select {
-		case <-ctx.Done():
-		case <-donec:
-			return
+// deadlineStopper is DeadlineStopper for two contexts driving the same deadline.
+// own is the context of the unwrapped wrapper, it may be nil.
+func deadlineStopper(ctx, own context.Context, dead func(time.Time) error, prev func() time.Time) func() {
+	if own != nil && own.Done() == nil {
+		own = nil
+	}
+
+	if ctx.Done() == nil {
+		if own == nil {
+			return nop
 		}
 
-		defer mu.Unlock()
-		mu.Lock()
+		ctx, own = own, nil
+	}
+
+	s := &stopper{dead: dead, prev: deadlineOf(prev)}
+
+	// unknown caller deadline must not be overwritten
+	if prev != nil {
+		dl := s.prev
+
+		for _, c := range [...]context.Context{ctx, own} {
+			if c == nil {
+				continue
+			}
+
+			if t, ok := c.Deadline(); ok && (dl.IsZero() || t.Before(dl)) {
+				dl = t
+				s.set = true
+			}
+		}
 
-		select {
-		case <-donec:
-			return
-		default:
+		if s.set {
+			_ = dead(dl)
 		}
+	}
+
+	s.stop = context.AfterFunc(ctx, s.kill)
+
+	if own != nil {
+		s.stopOwn = context.AfterFunc(own, s.kill)
+	}
+
+	return s.release
+}
+
+func (s *stopper) kill() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
 
-		_ = dead(time.Unix(1, 0))
-		killed = true
-	}()
+	if s.done {
+		return
+	}
+
+	_ = s.dead(time.Unix(1, 0))
+	s.killed = true
+}
 
-	return func() {
-		close(donec)
+func (s *stopper) release() {
+	stopped := s.stop()
 
-		defer mu.Unlock()
-		mu.Lock()
+	if s.stopOwn != nil {
+		stopped = s.stopOwn() && stopped
+	}
 
-		if killed {
-			_ = dead(time.Time{})
+	if stopped {
+		if s.set {
+			_ = s.dead(s.prev)
 		}
+
+		return
+	}
+
+	// kill was started, wait for it to finish or to see done.
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.done = true
+
+	if s.killed || s.set {
+		_ = s.dead(s.prev)
+	}
+}
+
+func deadlineOf(f func() time.Time) time.Time {
+	if f == nil {
+		return time.Time{}
+	}
+
+	return f()
+}
+
+func readDeadline(x any) func() time.Time {
+	d, ok := x.(ReadDeadliner)
+	if !ok {
+		return nil
 	}
+
+	return d.ReadDeadline
 }
 
+func acceptDeadline(x any) func() time.Time {
+	d, ok := x.(AcceptDeadliner)
+	if !ok {
+		return nil
+	}
+
+	return d.AcceptDeadline
+}
+
+func writeDeadline(x any) func() time.Time {
+	d, ok := x.(WriteDeadliner)
+	if !ok {
+		return nil
+	}
+
+	return d.WriteDeadline
+}
+
+func nop() {}
+
 func isTimeout(err error) bool {
 	to, ok := err.(interface{ Timeout() bool })
 
@@ -251,16 +490,56 @@ func isTimeout(err error) bool {
 }
 
 // FixError replaces internal error caused by operation abortion
-// and replaces it with one returned by ctx.Err if context was canceled.
+// with AbortError carrying context.Cause if context was canceled
+// or its deadline has passed.
+// The operation name is taken from *net.OpError if err has it.
 // Otherwise it returns the error unchanged.
+//
+// The returned error is no longer ctx.Err() itself,
+// so err == context.Canceled checks must be replaced with errors.Is.
 func FixError(ctx context.Context, err error) error {
-	if isTimeout(err) {
-		select {
-		case <-ctx.Done():
-			err = ctx.Err()
-		default:
+	return FixErrorOp(ctx, "", err)
+}
+
+// FixErrorOp is the same as FixError but with explicit operation name.
+func FixErrorOp(ctx context.Context, op string, err error) error {
+	if !isTimeout(err) {
+		return err
+	}
+
+	var ae *AbortError
+	if errors.As(err, &ae) {
+		return err
+	}
+
+	cerr := ctx.Err()
+
+	// conn deadline could fire a bit earlier than the context timer
+	if dl, ok := ctx.Deadline(); cerr == nil && ok && !time.Now().Before(dl) {
+		cerr = context.DeadlineExceeded
+	}
+
+	if cerr == nil {
+		return err
+	}
+
+	if op == "" {
+		var oe *net.OpError
+
+		if errors.As(err, &oe) {
+			op = oe.Op
 		}
 	}
 
-	return err
+	cause := context.Cause(ctx)
+	if cause == nil {
+		cause = cerr
+	}
+
+	return &AbortError{
+		Op:     op,
+		Cause:  cause,
+		CtxErr: cerr,
+		Err:    err,
+	}
 }
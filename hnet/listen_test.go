@@ -0,0 +1,390 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoppableConnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c := NewStoppableConn(ctx, a)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := c.Read(make([]byte, 10))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	go func() {
+		_, _ = b.Write([]byte("data"))
+	}()
+
+	n, err := a.Read(make([]byte, 10))
+	if n != 4 || err != nil {
+		t.Errorf("read after cancel: %v %v", n, err)
+	}
+}
+
+func TestStoppableConnCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c := NewStoppableConn(ctx, a)
+
+	cause := errors.New("peer is gone")
+
+	time.AfterFunc(10*time.Millisecond, func() { cancel(cause) })
+
+	_, err := c.Read(make([]byte, 10))
+	if !errors.Is(err, cause) || !errors.Is(err, context.Canceled) || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected cause, got %v", err)
+	}
+
+	if exp := "read: peer is gone"; err.Error() != exp {
+		t.Errorf("error message: %q, want %q", err, exp)
+	}
+
+	if IsTimeout(err) || !IsCanceled(err) {
+		t.Errorf("classified as timeout: %v", err)
+	}
+}
+
+func TestFixErrorWrapped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+
+	err := FixErrorOp(ctx, "read", os.ErrDeadlineExceeded)
+	werr := &net.OpError{Op: "read", Net: "tcp", Err: err}
+
+	if got := FixErrorOp(ctx, "read", werr); got != werr {
+		t.Errorf("wrapped abort error is wrapped again: %v", got)
+	}
+}
+
+func TestStoppableConnKeepsDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	c := NewStoppableConn(ctx, a)
+
+	dl := time.Now().Add(50 * time.Millisecond)
+	_ = c.SetReadDeadline(dl)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := c.Read(make([]byte, 10))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	if d := c.(ReadDeadliner).ReadDeadline(); !d.Equal(dl) {
+		t.Errorf("read deadline: %v, want %v", d, dl)
+	}
+
+	_, err = a.Read(make([]byte, 10))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected user deadline to be restored, got %v", err)
+	}
+
+	c = NewStoppableConn(context.Background(), a)
+
+	dl = time.Now().Add(time.Hour)
+	_ = c.SetReadDeadline(dl)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = Read(ctx, c, make([]byte, 10))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if d := c.(ReadDeadliner).ReadDeadline(); !d.Equal(dl) {
+		t.Errorf("read deadline after Read: %v, want %v", d, dl)
+	}
+}
+
+func TestReadContextDeadline(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	user := time.Now().Add(time.Hour)
+	c := &deadlineConn{Conn: a, read: user}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Read(ctx, c, make([]byte, 10))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if c.set.IsZero() || !c.set.Equal(user) {
+		t.Errorf("expected user deadline restored, got %v", c.set)
+	}
+
+	c.read = time.Now().Add(5 * time.Millisecond)
+	_ = c.SetReadDeadline(c.read)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	_, err = Read(ctx, c, make([]byte, 10))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected user deadline to fire, got %v", err)
+	}
+}
+
+func TestReadPlainConnDeadline(t *testing.T) {
+	a, b := tcpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	// plain conn doesn't report its deadline, so it must be left as is
+	_ = a.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := Read(ctx, a, make([]byte, 10))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected user deadline to fire, got %v", err)
+	}
+
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("user deadline ignored: read took %v", d)
+	}
+
+	_, err = a.Read(make([]byte, 10))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected user deadline to stay, got %v", err)
+	}
+
+	// context deadline still aborts the operation
+	_ = a.SetReadDeadline(time.Time{})
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = Read(ctx, a, make([]byte, 10))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAcceptKeepsDeadline(t *testing.T) {
+	l0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l0.Close()
+
+	l := NewStoppableListener(context.Background(), l0)
+
+	dl := time.Now().Add(time.Hour)
+	_ = l.(interface{ SetDeadline(time.Time) error }).SetDeadline(dl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = Accept(ctx, l)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if d := l.(AcceptDeadliner).AcceptDeadline(); !d.Equal(dl) {
+		t.Errorf("accept deadline: %v, want %v", d, dl)
+	}
+
+	dl = time.Now().Add(50 * time.Millisecond)
+	_ = l.(interface{ SetDeadline(time.Time) error }).SetDeadline(dl)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err = Accept(ctx, l)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// user deadline is restored on the listener itself
+	_, err = l0.Accept()
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected user deadline to be restored, got %v", err)
+	}
+}
+
+func TestStoppableListenerCancel(t *testing.T) {
+	l0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l0.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := NewStoppableListener(ctx, l0)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// the listener context is respected when it's passed to Accept
+	_, err = Accept(context.Background(), l)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	_, err = l.Accept()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	if _, ok := NewStoppableListener(ctx, struct{ net.Listener }{l0}).(interface{ SetDeadline(time.Time) error }); ok {
+		t.Errorf("SetDeadline of a listener without one")
+	}
+}
+
+func BenchmarkStopper(b *testing.B) {
+	dead := func(time.Time) error { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b.Run("Background", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			Stopper(context.Background(), dead)()
+		}
+	})
+
+	b.Run("AfterFunc", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			Stopper(ctx, dead)()
+		}
+	})
+
+	b.Run("Goroutine", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			goroutineStopper(ctx, dead)()
+		}
+	})
+}
+
+func BenchmarkStoppableConnRead(b *testing.B) {
+	c := NewStoppableConn(context.Background(), nopConn{})
+	buf := make([]byte, 10)
+
+	b.Run("Method", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = c.Read(buf)
+		}
+	})
+
+	b.Run("Func", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, _ = Read(context.Background(), c, buf)
+		}
+	})
+}
+
+type deadlineConn struct {
+	net.Conn
+
+	read time.Time
+	set  time.Time
+}
+
+func (c *deadlineConn) ReadDeadline() time.Time { return c.read }
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.set = t
+
+	return c.Conn.SetReadDeadline(t)
+}
+
+// nopConn reads and writes immediately.
+type nopConn struct {
+	net.Conn
+}
+
+func (nopConn) Read(p []byte) (int, error)       { return len(p), nil }
+func (nopConn) Write(p []byte) (int, error)      { return len(p), nil }
+func (nopConn) SetReadDeadline(time.Time) error  { return nil }
+func (nopConn) SetWriteDeadline(time.Time) error { return nil }
+
+// goroutineStopper is the previous Stopper implementation kept for comparison.
+func goroutineStopper(ctx context.Context, dead func(time.Time) error) func() {
+	donec := make(chan struct{})
+
+	var mu sync.Mutex
+	var killed bool
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-donec:
+			return
+		}
+
+		defer mu.Unlock()
+		mu.Lock()
+
+		select {
+		case <-donec:
+			return
+		default:
+		}
+
+		_ = dead(time.Unix(1, 0))
+		killed = true
+	}()
+
+	return func() {
+		close(donec)
+
+		defer mu.Unlock()
+		mu.Lock()
+
+		if killed {
+			_ = dead(time.Time{})
+		}
+	}
+}
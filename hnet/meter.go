@@ -0,0 +1,284 @@
+package hnet
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"nikand.dev/go/hacked/htime"
+)
+
+type (
+	// MeteredConn counts bytes, calls, errors and time spent in Read and Write.
+	// ReadFrom and WriteTo are not implemented, so all the traffic is metered.
+	MeteredConn struct {
+		net.Conn
+
+		onClose func(MeterStats)
+		once    sync.Once
+
+		m meter
+	}
+
+	// MeteredListener counts accepted connections
+	// and aggregates stats of the closed ones.
+	// Accepted connections are *MeteredConn.
+	MeteredListener struct {
+		net.Listener
+
+		onClose func(MeterStats)
+
+		accepted     atomic.Int64
+		acceptErrors atomic.Int64
+		active       atomic.Int64
+
+		mu     sync.Mutex
+		closed MeterStats
+	}
+
+	// ListenerMeter is the listener returned by NewMeteredListener.
+	ListenerMeter interface {
+		net.Listener
+
+		Stats() ListenerStats
+	}
+
+	// meteredDeadlineListener is a MeteredListener
+	// which wrapped listener has SetDeadline.
+	meteredDeadlineListener struct {
+		*MeteredListener
+	}
+
+	MeterStats struct {
+		Read    int64 // bytes
+		Written int64
+
+		Reads  int64 // calls
+		Writes int64
+
+		// ReadTime and WriteTime is the time spent blocked in the calls.
+		ReadTime  time.Duration
+		WriteTime time.Duration
+
+		Errors ErrorStats
+	}
+
+	// ErrorStats counts errors by class.
+	// io.EOF is not an error.
+	ErrorStats struct {
+		Canceled int64
+		Timeout  int64
+		Closed   int64
+		Reset    int64
+		Refused  int64
+		Other    int64
+	}
+
+	ListenerStats struct {
+		Accepted     int64
+		AcceptErrors int64
+		Active       int64
+
+		// Closed is the total of the closed connections.
+		Closed MeterStats
+	}
+
+	meter struct {
+		read, written atomic.Int64
+		reads, writes atomic.Int64
+		rtime, wtime  atomic.Int64
+
+		canceled, timeout, closed atomic.Int64
+		reset, refused, other     atomic.Int64
+	}
+)
+
+// NewMeteredConn wraps c to meter its usage.
+// onClose, if not nil, is called once on the first Close with the final stats.
+func NewMeteredConn(c net.Conn, onClose func(MeterStats)) *MeteredConn {
+	return &MeteredConn{
+		Conn:    c,
+		onClose: onClose,
+	}
+}
+
+func (c *MeteredConn) Read(p []byte) (n int, err error) {
+	start := htime.Monotonic()
+	n, err = c.Conn.Read(p)
+	c.m.rtime.Add(htime.Monotonic() - start)
+
+	c.m.reads.Add(1)
+	c.m.read.Add(int64(n))
+	c.m.error(err)
+
+	return n, err
+}
+
+func (c *MeteredConn) Write(p []byte) (n int, err error) {
+	start := htime.Monotonic()
+	n, err = c.Conn.Write(p)
+	c.m.wtime.Add(htime.Monotonic() - start)
+
+	c.m.writes.Add(1)
+	c.m.written.Add(int64(n))
+	c.m.error(err)
+
+	return n, err
+}
+
+// Close closes the connection and calls onClose callback the first time.
+func (c *MeteredConn) Close() error {
+	err := c.Conn.Close()
+
+	c.once.Do(func() {
+		if c.onClose != nil {
+			c.onClose(c.Stats())
+		}
+	})
+
+	return err
+}
+
+// CloseWrite shuts down the writing side of the connection.
+// errors.ErrUnsupported is returned if the wrapped connection doesn't support it.
+func (c *MeteredConn) CloseWrite() error {
+	return closeWrite(c.Conn)
+}
+
+// CloseRead shuts down the reading side of the connection.
+func (c *MeteredConn) CloseRead() error {
+	return closeRead(c.Conn)
+}
+
+func (c *MeteredConn) SyscallConn() (syscall.RawConn, error) {
+	return syscallConn(c.Conn)
+}
+
+// Stats returns a snapshot of the counters.
+func (c *MeteredConn) Stats() MeterStats {
+	return c.m.stats()
+}
+
+// NewMeteredListener wraps l to meter accepted connections.
+// onClose, if not nil, is called once for each accepted connection when it's closed.
+// The result has SetDeadline only if l has it,
+// so hnet.Accept and AcceptLoop can abort it.
+func NewMeteredListener(l net.Listener, onClose func(MeterStats)) ListenerMeter {
+	ml := &MeteredListener{
+		Listener: l,
+		onClose:  onClose,
+	}
+
+	if _, ok := l.(interface {
+		SetDeadline(time.Time) error
+	}); ok {
+		return meteredDeadlineListener{ml}
+	}
+
+	return ml
+}
+
+func (l *MeteredListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		l.acceptErrors.Add(1)
+		return nil, err
+	}
+
+	l.accepted.Add(1)
+	l.active.Add(1)
+
+	return NewMeteredConn(c, l.connClosed), nil
+}
+
+// SetDeadline sets the wrapped listener Accept deadline.
+func (l meteredDeadlineListener) SetDeadline(t time.Time) error {
+	return l.Listener.(interface {
+		SetDeadline(time.Time) error
+	}).SetDeadline(t)
+}
+
+// Stats returns a snapshot of the counters.
+func (l *MeteredListener) Stats() ListenerStats {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+
+	return ListenerStats{
+		Accepted:     l.accepted.Load(),
+		AcceptErrors: l.acceptErrors.Load(),
+		Active:       l.active.Load(),
+		Closed:       l.closed,
+	}
+}
+
+func (l *MeteredListener) connClosed(s MeterStats) {
+	l.mu.Lock()
+	l.closed.add(s)
+	l.active.Add(-1)
+	l.mu.Unlock()
+
+	if l.onClose != nil {
+		l.onClose(s)
+	}
+}
+
+func (m *meter) error(err error) {
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+	case IsCanceled(err):
+		m.canceled.Add(1)
+	case IsTimeout(err):
+		m.timeout.Add(1)
+	case IsClosed(err):
+		m.closed.Add(1)
+	case IsConnReset(err):
+		m.reset.Add(1)
+	case IsRefused(err):
+		m.refused.Add(1)
+	default:
+		m.other.Add(1)
+	}
+}
+
+func (m *meter) stats() MeterStats {
+	return MeterStats{
+		Read:      m.read.Load(),
+		Written:   m.written.Load(),
+		Reads:     m.reads.Load(),
+		Writes:    m.writes.Load(),
+		ReadTime:  time.Duration(m.rtime.Load()),
+		WriteTime: time.Duration(m.wtime.Load()),
+		Errors: ErrorStats{
+			Canceled: m.canceled.Load(),
+			Timeout:  m.timeout.Load(),
+			Closed:   m.closed.Load(),
+			Reset:    m.reset.Load(),
+			Refused:  m.refused.Load(),
+			Other:    m.other.Load(),
+		},
+	}
+}
+
+func (s *MeterStats) add(x MeterStats) {
+	s.Read += x.Read
+	s.Written += x.Written
+	s.Reads += x.Reads
+	s.Writes += x.Writes
+	s.ReadTime += x.ReadTime
+	s.WriteTime += x.WriteTime
+
+	s.Errors.add(x.Errors)
+}
+
+func (s *ErrorStats) add(x ErrorStats) {
+	s.Canceled += x.Canceled
+	s.Timeout += x.Timeout
+	s.Closed += x.Closed
+	s.Reset += x.Reset
+	s.Refused += x.Refused
+	s.Other += x.Other
+}
@@ -0,0 +1,194 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMeteredConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+
+	var final MeterStats
+	var calls int
+
+	c := NewMeteredConn(a, func(s MeterStats) {
+		final = s
+		calls++
+	})
+
+	go func() {
+		_, _ = b.Write([]byte("data"))
+		_, _ = io.Copy(io.Discard, b)
+	}()
+
+	buf := make([]byte, 10)
+
+	n, err := c.Read(buf)
+	if n != 4 || err != nil {
+		t.Fatalf("read: %v %v", n, err)
+	}
+
+	_, err = c.Write([]byte("response"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_ = c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, err = c.Read(buf)
+	if !os.IsTimeout(err) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	st := c.Stats()
+	if st.Read != 4 || st.Written != 8 || st.Reads != 2 || st.Writes != 1 || st.Errors.Timeout != 1 || st.ReadTime < 10*time.Millisecond {
+		t.Errorf("stats: %+v", st)
+	}
+
+	_ = c.Close()
+	_ = c.Close()
+
+	_, _ = c.Read(buf)
+
+	if calls != 1 || final != st {
+		t.Errorf("on close: %d %+v", calls, final)
+	}
+
+	if st := c.Stats(); st.Errors.Closed != 1 {
+		t.Errorf("closed errors: %+v", st.Errors)
+	}
+}
+
+func TestMeteredListener(t *testing.T) {
+	l0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	closed := make(chan MeterStats, 1)
+	l := NewMeteredListener(l0, func(s MeterStats) { closed <- s })
+	defer l.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+
+		defer c.Close()
+
+		_, _ = c.Write([]byte("hello"))
+	}()
+
+	c, err := l.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+
+	if st := l.Stats(); st.Accepted != 1 || st.Active != 1 {
+		t.Errorf("stats: %+v", st)
+	}
+
+	_, _ = io.Copy(io.Discard, c)
+	_ = c.Close()
+
+	s := <-closed
+
+	if st := l.Stats(); st.Active != 0 || st.Closed != s || s.Read != 5 {
+		t.Errorf("stats: %+v, closed %+v", st, s)
+	}
+
+	_ = l.Close()
+
+	_, err = l.Accept()
+	if !IsClosed(err) {
+		t.Errorf("expected closed, got %v", err)
+	}
+
+	if st := l.Stats(); st.AcceptErrors != 1 {
+		t.Errorf("stats: %+v", st)
+	}
+}
+
+func TestMeteredListenerDeadline(t *testing.T) {
+	l0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l0.Close()
+
+	l := NewMeteredListener(l0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err = Accept(ctx, l)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	l = NewMeteredListener(struct{ net.Listener }{l0}, nil)
+
+	if _, ok := l.(interface{ SetDeadline(time.Time) error }); ok {
+		t.Errorf("SetDeadline of a listener without one")
+	}
+}
+
+func TestMeteredForwarding(t *testing.T) {
+	l0, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	l := NewMeteredListener(l0, nil)
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+
+		defer c.Close()
+
+		_, _ = io.Copy(c, c)
+	}()
+
+	err = AcceptLoop(ctx, l, func(c net.Conn) {
+		defer c.Close()
+
+		_, _ = c.Write([]byte("data"))
+
+		// half-close is forwarded, so the echo peer sees EOF
+		err := CloseWriter(c)
+		if err != nil {
+			t.Errorf("close write: %v", err)
+		}
+
+		data, err := io.ReadAll(c)
+		if string(data) != "data" || err != nil {
+			t.Errorf("read: %q %v", data, err)
+		}
+
+		time.AfterFunc(10*time.Millisecond, cancel)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+
+	if st := l.Stats(); st.Accepted != 1 || st.Closed.Read != 4 {
+		t.Errorf("stats: %+v", st)
+	}
+}
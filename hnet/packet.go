@@ -0,0 +1,296 @@
+package hnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+type (
+	StoppablePacketConn struct {
+		context.Context
+		net.PacketConn
+
+		dl *deadlines
+	}
+
+	// UDPConn is the *net.UDPConn method set.
+	UDPConn interface {
+		net.Conn
+		net.PacketConn
+		syscall.Conn
+
+		ReaderFromUDP
+		ReaderFromUDPAddrPort
+		ReaderMsgUDP
+		ReaderMsgUDPAddrPort
+
+		WriterToUDP
+		WriterToUDPAddrPort
+		WriterMsgUDP
+		WriterMsgUDPAddrPort
+	}
+
+	// stoppableUDPConn is a StoppablePacketConn wrapping *net.UDPConn.
+	stoppableUDPConn struct {
+		StoppablePacketConn
+
+		u *net.UDPConn
+	}
+
+	// stoppableSyscallPacketConn wraps packet connections with SyscallConn, such as *net.IPConn.
+	stoppableSyscallPacketConn struct {
+		StoppablePacketConn
+	}
+)
+
+// NewStoppablePacketConn wraps packet connection to have the same interface,
+// but returned connection aborts on context cancellation.
+// *net.UDPConn is wrapped with NewStoppableUDPConn,
+// SyscallConn is forwarded if c has it.
+func NewStoppablePacketConn(ctx context.Context, c net.PacketConn) net.PacketConn {
+	if u, ok := c.(*net.UDPConn); ok {
+		return NewStoppableUDPConn(ctx, u)
+	}
+
+	sc := StoppablePacketConn{
+		Context:    ctx,
+		PacketConn: c,
+		dl:         &deadlines{},
+	}
+
+	if _, ok := c.(syscall.Conn); ok {
+		return stoppableSyscallPacketConn{sc}
+	}
+
+	return sc
+}
+
+// NewStoppableUDPConn is NewStoppablePacketConn for UDP connections.
+// UDP specific methods are forwarded and abort on context cancellation as well.
+func NewStoppableUDPConn(ctx context.Context, c *net.UDPConn) UDPConn {
+	return stoppableUDPConn{
+		StoppablePacketConn: StoppablePacketConn{
+			Context:    ctx,
+			PacketConn: c,
+			dl:         &deadlines{},
+		},
+		u: c,
+	}
+}
+
+// NetPacketConn returns the wrapped connection.
+func (c StoppablePacketConn) NetPacketConn() net.PacketConn {
+	return c.PacketConn
+}
+
+func (c StoppablePacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	if c.Context.Done() == nil {
+		return c.PacketConn.ReadFrom(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.PacketConn.SetReadDeadline, c.ReadDeadline)()
+
+	n, addr, err = c.PacketConn.ReadFrom(p)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c StoppablePacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	if c.Context.Done() == nil {
+		return c.PacketConn.WriteTo(p, addr)
+	}
+
+	defer DeadlineStopper(c.Context, c.PacketConn.SetWriteDeadline, c.WriteDeadline)()
+
+	n, err = c.PacketConn.WriteTo(p, addr)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) Read(p []byte) (n int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.Read(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetReadDeadline, c.ReadDeadline)()
+
+	n, err = c.u.Read(p)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c stoppableUDPConn) ReadFromUDP(p []byte) (n int, addr *net.UDPAddr, err error) {
+	if c.Context.Done() == nil {
+		return c.u.ReadFromUDP(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetReadDeadline, c.ReadDeadline)()
+
+	n, addr, err = c.u.ReadFromUDP(p)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c stoppableUDPConn) ReadFromUDPAddrPort(p []byte) (n int, addr netip.AddrPort, err error) {
+	if c.Context.Done() == nil {
+		return c.u.ReadFromUDPAddrPort(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetReadDeadline, c.ReadDeadline)()
+
+	n, addr, err = c.u.ReadFromUDPAddrPort(p)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c stoppableUDPConn) ReadMsgUDP(p, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	if c.Context.Done() == nil {
+		return c.u.ReadMsgUDP(p, oob)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetReadDeadline, c.ReadDeadline)()
+
+	n, oobn, flags, addr, err = c.u.ReadMsgUDP(p, oob)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c stoppableUDPConn) ReadMsgUDPAddrPort(p, oob []byte) (n, oobn, flags int, addr netip.AddrPort, err error) {
+	if c.Context.Done() == nil {
+		return c.u.ReadMsgUDPAddrPort(p, oob)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetReadDeadline, c.ReadDeadline)()
+
+	n, oobn, flags, addr, err = c.u.ReadMsgUDPAddrPort(p, oob)
+	err = FixErrorOp(c.Context, "read", err)
+
+	return
+}
+
+func (c stoppableUDPConn) Write(p []byte) (n int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.Write(p)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetWriteDeadline, c.WriteDeadline)()
+
+	n, err = c.u.Write(p)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) WriteToUDP(p []byte, addr *net.UDPAddr) (n int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.WriteToUDP(p, addr)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetWriteDeadline, c.WriteDeadline)()
+
+	n, err = c.u.WriteToUDP(p, addr)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) WriteToUDPAddrPort(p []byte, addr netip.AddrPort) (n int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.WriteToUDPAddrPort(p, addr)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetWriteDeadline, c.WriteDeadline)()
+
+	n, err = c.u.WriteToUDPAddrPort(p, addr)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) WriteMsgUDP(p, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.WriteMsgUDP(p, oob, addr)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetWriteDeadline, c.WriteDeadline)()
+
+	n, oobn, err = c.u.WriteMsgUDP(p, oob, addr)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) WriteMsgUDPAddrPort(p, oob []byte, addr netip.AddrPort) (n, oobn int, err error) {
+	if c.Context.Done() == nil {
+		return c.u.WriteMsgUDPAddrPort(p, oob, addr)
+	}
+
+	defer DeadlineStopper(c.Context, c.u.SetWriteDeadline, c.WriteDeadline)()
+
+	n, oobn, err = c.u.WriteMsgUDPAddrPort(p, oob, addr)
+	err = FixErrorOp(c.Context, "write", err)
+
+	return
+}
+
+func (c stoppableUDPConn) RemoteAddr() net.Addr { return c.u.RemoteAddr() }
+
+func (c stoppableUDPConn) SyscallConn() (syscall.RawConn, error) { return c.u.SyscallConn() }
+
+func (c stoppableSyscallPacketConn) SyscallConn() (syscall.RawConn, error) {
+	return syscallConn(c.PacketConn)
+}
+
+// SetDeadline sets the underlying connection deadlines and remembers them,
+// so they are restored after context deadline or cancellation.
+func (c StoppablePacketConn) SetDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setRead(t)
+		c.dl.setWrite(t)
+	}
+
+	return c.PacketConn.SetDeadline(t)
+}
+
+func (c StoppablePacketConn) SetReadDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setRead(t)
+	}
+
+	return c.PacketConn.SetReadDeadline(t)
+}
+
+func (c StoppablePacketConn) SetWriteDeadline(t time.Time) error {
+	if c.dl != nil {
+		c.dl.setWrite(t)
+	}
+
+	return c.PacketConn.SetWriteDeadline(t)
+}
+
+// ReadDeadline returns the last read deadline set by the user.
+func (c StoppablePacketConn) ReadDeadline() time.Time {
+	if c.dl == nil {
+		return deadlineOf(readDeadline(c.PacketConn))
+	}
+
+	return c.dl.readDeadline()
+}
+
+// WriteDeadline returns the last write deadline set by the user.
+func (c StoppablePacketConn) WriteDeadline() time.Time {
+	if c.dl == nil {
+		return deadlineOf(writeDeadline(c.PacketConn))
+	}
+
+	return c.dl.writeDeadline()
+}
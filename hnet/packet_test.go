@@ -0,0 +1,84 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStoppablePacketConn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := udpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	sa := NewStoppablePacketConn(ctx, a)
+
+	w, ok := sa.(WriterToUDPAddrPort)
+	if !ok {
+		t.Fatalf("expected WriterToUDPAddrPort")
+	}
+
+	_, err := w.WriteToUDPAddrPort([]byte("ping"), b.LocalAddr().(*net.UDPAddr).AddrPort())
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	buf := make([]byte, 16)
+
+	n, addr, err := b.ReadFromUDPAddrPort(buf)
+	if err != nil || string(buf[:n]) != "ping" || addr != a.LocalAddr().(*net.UDPAddr).AddrPort() {
+		t.Errorf("read: %q %v %v", buf[:n], addr, err)
+	}
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, _, _, _, err = sa.(ReaderMsgUDPAddrPort).ReadMsgUDPAddrPort(buf, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func TestStoppablePacketConnMethods(t *testing.T) {
+	a, b := udpPair(t)
+	defer a.Close()
+	defer b.Close()
+
+	if _, ok := NewStoppablePacketConn(context.Background(), a).(UDPConn); !ok {
+		t.Errorf("expected UDPConn")
+	}
+
+	c := NewStoppablePacketConn(context.Background(), struct{ net.PacketConn }{b})
+
+	if _, ok := c.(ReaderMsgUDPAddrPort); ok {
+		t.Errorf("ReadMsgUDPAddrPort of a conn without one")
+	}
+
+	if _, ok := c.(syscall.Conn); ok {
+		t.Errorf("SyscallConn of a conn without one")
+	}
+}
+
+func udpPair(tb testing.TB) (*net.UDPConn, *net.UDPConn) {
+	tb.Helper()
+
+	lo := net.UDPAddrFromAddrPort(netip.MustParseAddrPort("127.0.0.1:0"))
+
+	a, err := net.ListenUDP("udp", lo)
+	if err != nil {
+		tb.Fatalf("listen: %v", err)
+	}
+
+	b, err := net.ListenUDP("udp", lo)
+	if err != nil {
+		tb.Fatalf("listen: %v", err)
+	}
+
+	return a, b
+}
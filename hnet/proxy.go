@@ -0,0 +1,91 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Proxy copies data between a and b in both directions until both are done.
+// When one direction reaches EOF, the destination write side is closed with CloseWriter.
+// Context cancelation or an error in one direction aborts both of them.
+// Connections are copied with io.Copy directly, so splice and sendfile are used if possible.
+// StoppableConn is unwrapped for that, its context is respected as well.
+// Errors of both directions are joined.
+// Connections are not closed.
+func Proxy(ctx context.Context, a, b net.Conn) (aToB, bToA int64, err error) {
+	pctx := ctx
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ar, actx, release := readStopper(ctx, a)
+	defer release()
+
+	aw, _, release := writeStopper(ctx, a)
+	defer release()
+
+	br, bctx, release := readStopper(ctx, b)
+	defer release()
+
+	bw, _, release := writeStopper(ctx, b)
+	defer release()
+
+	var wg sync.WaitGroup
+	var errBA error
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		bToA, errBA = proxyCopy(aw, br, cancel)
+	}()
+
+	aToB, errAB := proxyCopy(bw, ar, cancel)
+
+	wg.Wait()
+
+	errAB = proxyError(ctx, fixErrors(pctx, actx, bctx, "", errAB))
+	errBA = proxyError(ctx, fixErrors(pctx, bctx, actx, "", errBA))
+
+	if isAbort(errAB) && isAbort(errBA) {
+		errBA = nil // the same cancelation
+	}
+
+	return aToB, bToA, errors.Join(errAB, errBA)
+}
+
+func proxyCopy(dst io.Writer, src io.Reader, abort func()) (n int64, err error) {
+	n, err = io.Copy(dst, src)
+	if err == nil {
+		err = CloseWriter(dst)
+	}
+
+	if err != nil {
+		abort()
+	}
+
+	return n, err
+}
+
+// proxyError drops errors caused by aborting one direction
+// because of an error in the other one.
+func proxyError(ctx context.Context, err error) error {
+	if err == nil || isAbort(err) {
+		return err
+	}
+
+	if ctx.Err() != nil && isTimeout(err) {
+		return nil
+	}
+
+	return err
+}
+
+func isAbort(err error) bool {
+	_, ok := err.(*AbortError)
+	return ok
+}
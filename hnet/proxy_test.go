@@ -0,0 +1,116 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProxy(t *testing.T) {
+	client, a := tcpPair(t)
+	defer client.Close()
+	defer a.Close()
+
+	b, server := tcpPair(t)
+	defer b.Close()
+	defer server.Close()
+
+	go func() {
+		data, _ := io.ReadAll(server)
+		_, _ = server.Write(append(data, " world"...))
+		_ = CloseWriter(server)
+	}()
+
+	go func() {
+		_, _ = client.Write([]byte("hello"))
+		_ = CloseWriter(client)
+	}()
+
+	type res struct {
+		ab, ba int64
+		err    error
+	}
+
+	resc := make(chan res, 1)
+
+	go func() {
+		ab, ba, err := Proxy(context.Background(), a, b)
+		resc <- res{ab, ba, err}
+	}()
+
+	data, err := io.ReadAll(client)
+	if err != nil || string(data) != "hello world" {
+		t.Errorf("client read: %q %v", data, err)
+	}
+
+	if r := <-resc; r.ab != 5 || r.ba != 11 || r.err != nil {
+		t.Errorf("proxy: %+v", r)
+	}
+}
+
+func TestProxyCancel(t *testing.T) {
+	client, a := tcpPair(t)
+	defer client.Close()
+	defer a.Close()
+
+	b, server := tcpPair(t)
+	defer b.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, _, err := Proxy(ctx, a, b)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func TestProxyKeepsDeadline(t *testing.T) {
+	client, a := tcpPair(t)
+	defer client.Close()
+	defer a.Close()
+
+	b, server := tcpPair(t)
+	defer b.Close()
+	defer server.Close()
+
+	sa := NewStoppableConn(context.Background(), a)
+	sb := NewStoppableConn(context.Background(), b)
+
+	dl := time.Now().Add(300 * time.Millisecond)
+	_ = sa.SetDeadline(dl)
+	_ = sb.SetDeadline(dl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, _, err := Proxy(ctx, sa, sb)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	// the caller deadline is back on the sockets, neither the abort one nor none
+	guard := time.AfterFunc(time.Second, func() {
+		_ = a.Close()
+		_ = b.Close()
+	})
+	defer guard.Stop()
+
+	for _, c := range []net.Conn{a, b} {
+		_, err = c.Read(make([]byte, 10))
+		now := time.Now()
+
+		if !errors.Is(err, os.ErrDeadlineExceeded) || now.Before(dl.Add(-50*time.Millisecond)) {
+			t.Errorf("read after proxy: %v at %v before the deadline", err, dl.Sub(now))
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+type (
+	// Server is a TCP (or any stream) server built on Accept and StoppableConn.
+	// It tracks live connections and shuts down gracefully.
+	Server struct {
+		// Handler is called in its own goroutine for each connection.
+		// Connection is wrapped with NewStoppableConn and closed after Handler returns.
+		// ctx is canceled when Serve context is canceled or on hard close.
+		Handler func(ctx context.Context, c net.Conn)
+
+		// MaxConns limits the number of concurrent connections.
+		// Accept waits for a slot if the limit is reached. Zero means no limit.
+		MaxConns int
+
+		// HardCloseTimeout is the time Shutdown waits for handlers
+		// before closing all the connections. Zero means wait for Shutdown context.
+		HardCloseTimeout time.Duration
+
+		mu    sync.Mutex
+		conns map[net.Conn]struct{}
+		sem   chan struct{}
+		wg    sync.WaitGroup
+
+		quit     context.Context // canceled on Shutdown
+		stopQuit context.CancelFunc
+		hard     context.Context // canceled on hard close
+		stopHard context.CancelFunc
+	}
+)
+
+// ErrServerClosed is returned by Serve after Shutdown or Close.
+var ErrServerClosed = errors.New("server closed")
+
+// Serve accepts connections on l until ctx is canceled or Shutdown is called.
+// Temporary Accept errors are retried with backoff as in AcceptLoop.
+// l must support SetDeadline to abort Accept, net.TCPListener does.
+// l is not closed.
+func (s *Server) Serve(ctx context.Context, l net.Listener) (err error) {
+	s.init()
+
+	if s.quit.Err() != nil {
+		return ErrServerClosed
+	}
+
+	actx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer context.AfterFunc(s.quit, cancel)()
+
+	var b backoff
+
+	for {
+		if s.sem != nil {
+			select {
+			case s.sem <- struct{}{}:
+			case <-actx.Done():
+				return s.serveErr(ctx)
+			}
+		}
+
+		c, err := Accept(actx, l)
+		if err != nil {
+			s.release()
+
+			if actx.Err() != nil {
+				return s.serveErr(ctx)
+			}
+
+			if !IsTemporary(err) {
+				return err
+			}
+
+			if b.wait(actx) != nil {
+				return s.serveErr(ctx)
+			}
+
+			continue
+		}
+
+		b.reset()
+		s.serve(ctx, c)
+	}
+}
+
+// Shutdown stops accepting new connections and waits for active handlers to finish.
+// If ctx is canceled or HardCloseTimeout passed, all the connections are closed,
+// handlers contexts are canceled, and the context error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.init()
+	s.stop()
+
+	if s.HardCloseTimeout != 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, s.HardCloseTimeout)
+		defer cancel()
+	}
+
+	donec := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(donec)
+	}()
+
+	select {
+	case <-donec:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.closeConns()
+
+	return ctx.Err()
+}
+
+// Close stops accepting new connections and closes all the active ones immediately.
+func (s *Server) Close() error {
+	s.init()
+	s.stop()
+	s.closeConns()
+
+	return nil
+}
+
+// Conns returns the number of active connections.
+func (s *Server) Conns() int {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	return len(s.conns)
+}
+
+func (s *Server) init() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	if s.conns != nil {
+		return
+	}
+
+	s.conns = make(map[net.Conn]struct{})
+
+	if s.MaxConns > 0 {
+		s.sem = make(chan struct{}, s.MaxConns)
+	}
+
+	s.quit, s.stopQuit = context.WithCancel(context.Background())
+	s.hard, s.stopHard = context.WithCancel(context.Background())
+}
+
+func (s *Server) serve(ctx context.Context, c net.Conn) {
+	s.mu.Lock()
+
+	if s.quit.Err() != nil {
+		s.mu.Unlock()
+		s.release()
+
+		_ = c.Close()
+
+		return
+	}
+
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer s.release()
+
+		hctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		defer context.AfterFunc(s.hard, cancel)()
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+
+			_ = c.Close()
+		}()
+
+		s.Handler(hctx, NewStoppableConn(hctx, c))
+	}()
+}
+
+// stop cancels quit under the lock, so no connections are added after that.
+func (s *Server) stop() {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	s.stopQuit()
+}
+
+func (s *Server) closeConns() {
+	s.stopHard()
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+
+	for c := range s.conns {
+		_ = c.Close()
+	}
+}
+
+func (s *Server) release() {
+	if s.sem != nil {
+		<-s.sem
+	}
+}
+
+func (s *Server) serveErr(ctx context.Context) error {
+	if s.quit.Err() != nil {
+		return ErrServerClosed
+	}
+
+	return ctx.Err()
+}
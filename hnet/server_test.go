@@ -0,0 +1,104 @@
+package hnet
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l.Close()
+
+	s := &Server{
+		MaxConns: 2,
+		Handler: func(ctx context.Context, c net.Conn) {
+			_, _ = io.Copy(c, c)
+		},
+	}
+
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- s.Serve(context.Background(), l)
+	}()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	defer c.Close()
+
+	_, _ = c.Write([]byte("ping"))
+
+	buf := make([]byte, 4)
+
+	_, err = io.ReadFull(c, buf)
+	if err != nil || string(buf) != "ping" || s.Conns() != 1 {
+		t.Fatalf("echo: %q %v, conns %d", buf, err, s.Conns())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = s.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected hard close, got %v", err)
+	}
+
+	if err = <-errc; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("serve: %v", err)
+	}
+
+	_, err = c.Read(buf)
+	if err != io.EOF {
+		t.Errorf("expected closed conn, got %v", err)
+	}
+
+	if err = s.Serve(context.Background(), l); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("serve after shutdown: %v", err)
+	}
+}
+
+func TestServerGraceful(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	defer l.Close()
+
+	s := &Server{
+		Handler: func(ctx context.Context, c net.Conn) {
+			_, _ = c.Write([]byte("bye"))
+		},
+	}
+
+	go func() {
+		_ = s.Serve(context.Background(), l)
+	}()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	defer c.Close()
+
+	data, err := io.ReadAll(c)
+	if err != nil || string(data) != "bye" {
+		t.Errorf("read: %q %v", data, err)
+	}
+
+	if err = s.Shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
@@ -34,14 +34,14 @@ func Write(ctx context.Context, w io.Writer, p []byte) (int, error) {
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.Write(p)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, err := w.Write(p)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, err
 }
@@ -50,14 +50,14 @@ func WriteTo(ctx context.Context, w WriterTo, p []byte, addr net.Addr) (int, err
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.WriteTo(p, addr)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, err := w.WriteTo(p, addr)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, err
 }
@@ -66,14 +66,14 @@ func WriteToUDP(ctx context.Context, w WriterToUDP, p []byte, addr *net.UDPAddr)
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.WriteToUDP(p, addr)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, err := w.WriteToUDP(p, addr)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, err
 }
@@ -82,14 +82,14 @@ func WriteToUDPAddrPort(ctx context.Context, w WriterToUDPAddrPort, p []byte, ad
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.WriteToUDPAddrPort(p, addr)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, err := w.WriteToUDPAddrPort(p, addr)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, err
 }
@@ -98,14 +98,14 @@ func WriteMsgUDP(ctx context.Context, w WriterMsgUDP, p, oob []byte, addr *net.U
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.WriteMsgUDP(p, oob, addr)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, oobn, err := w.WriteMsgUDP(p, oob, addr)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, oobn, err
 }
@@ -114,14 +114,14 @@ func WriteMsgUDPAddrPort(ctx context.Context, w WriterMsgUDPAddrPort, p, oob []b
 	d, ok := w.(interface {
 		SetWriteDeadline(time.Time) error
 	})
-	if !ok {
+	if !ok || ctx.Done() == nil {
 		return w.WriteMsgUDPAddrPort(p, oob, addr)
 	}
 
-	defer Stopper(ctx, d.SetWriteDeadline)()
+	defer DeadlineStopper(ctx, d.SetWriteDeadline, writeDeadline(w))()
 
 	n, oobn, err := w.WriteMsgUDPAddrPort(p, oob, addr)
-	err = FixError(ctx, err)
+	err = FixErrorOp(ctx, "write", err)
 
 	return n, oobn, err
 }
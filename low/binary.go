@@ -0,0 +1,190 @@
+package low
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// ErrOverflow is returned if varint doesn't fit into 64 bits.
+var ErrOverflow = errors.New("varint overflows a 64-bit integer")
+
+func (w *Buf) AppendUvarint(v uint64) { *w = binary.AppendUvarint(*w, v) }
+func (w *Buf) AppendVarint(v int64)   { *w = binary.AppendVarint(*w, v) }
+
+func (w *Buf) AppendUint16BE(v uint16) { *w = binary.BigEndian.AppendUint16(*w, v) }
+func (w *Buf) AppendUint32BE(v uint32) { *w = binary.BigEndian.AppendUint32(*w, v) }
+func (w *Buf) AppendUint64BE(v uint64) { *w = binary.BigEndian.AppendUint64(*w, v) }
+
+func (w *Buf) AppendUint16LE(v uint16) { *w = binary.LittleEndian.AppendUint16(*w, v) }
+func (w *Buf) AppendUint32LE(v uint32) { *w = binary.LittleEndian.AppendUint32(*w, v) }
+func (w *Buf) AppendUint64LE(v uint64) { *w = binary.LittleEndian.AppendUint64(*w, v) }
+
+func (w *Buf) AppendFloat32BE(v float32) { w.AppendUint32BE(math.Float32bits(v)) }
+func (w *Buf) AppendFloat64BE(v float64) { w.AppendUint64BE(math.Float64bits(v)) }
+
+func (w *Buf) AppendFloat32LE(v float32) { w.AppendUint32LE(math.Float32bits(v)) }
+func (w *Buf) AppendFloat64LE(v float64) { w.AppendUint64LE(math.Float64bits(v)) }
+
+// AppendLenBytes appends p prefixed with its uvarint length.
+func (w *Buf) AppendLenBytes(p []byte) {
+	w.AppendUvarint(uint64(len(p)))
+	*w = append(*w, p...)
+}
+
+// AppendLenString appends s prefixed with its uvarint length.
+func (w *Buf) AppendLenString(s string) {
+	w.AppendUvarint(uint64(len(s)))
+	*w = append(*w, s...)
+}
+
+func (r *BufReader) ReadUvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.Buf[r.R:])
+	if n <= 0 {
+		return 0, r.varintErr(n)
+	}
+
+	r.R += n
+
+	return v, nil
+}
+
+func (r *BufReader) ReadVarint() (int64, error) {
+	v, n := binary.Varint(r.Buf[r.R:])
+	if n <= 0 {
+		return 0, r.varintErr(n)
+	}
+
+	r.R += n
+
+	return v, nil
+}
+
+func (r *BufReader) ReadUint16BE() (uint16, error) {
+	p, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(p), nil
+}
+
+func (r *BufReader) ReadUint32BE() (uint32, error) {
+	p, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(p), nil
+}
+
+func (r *BufReader) ReadUint64BE() (uint64, error) {
+	p, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(p), nil
+}
+
+func (r *BufReader) ReadUint16LE() (uint16, error) {
+	p, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint16(p), nil
+}
+
+func (r *BufReader) ReadUint32LE() (uint32, error) {
+	p, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(p), nil
+}
+
+func (r *BufReader) ReadUint64LE() (uint64, error) {
+	p, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(p), nil
+}
+
+func (r *BufReader) ReadFloat32BE() (float32, error) {
+	v, err := r.ReadUint32BE()
+	return math.Float32frombits(v), err
+}
+
+func (r *BufReader) ReadFloat64BE() (float64, error) {
+	v, err := r.ReadUint64BE()
+	return math.Float64frombits(v), err
+}
+
+func (r *BufReader) ReadFloat32LE() (float32, error) {
+	v, err := r.ReadUint32LE()
+	return math.Float32frombits(v), err
+}
+
+func (r *BufReader) ReadFloat64LE() (float64, error) {
+	v, err := r.ReadUint64LE()
+	return math.Float64frombits(v), err
+}
+
+// ReadLenBytes reads uvarint length prefixed bytes.
+// Returned slice points into the buffer.
+func (r *BufReader) ReadLenBytes() ([]byte, error) {
+	st := r.R
+
+	l, err := r.ReadUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	if l > uint64(len(r.Buf)-r.R) {
+		r.R = st
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	p := r.Buf[r.R : r.R+int(l) : r.R+int(l)]
+	r.R += int(l)
+
+	return p, nil
+}
+
+// ReadLenString reads uvarint length prefixed string.
+func (r *BufReader) ReadLenString() (string, error) {
+	p, err := r.ReadLenBytes()
+
+	return string(p), err
+}
+
+// next consumes the next n bytes.
+// Nothing is consumed if there are less than n bytes left.
+func (r *BufReader) next(n int) ([]byte, error) {
+	switch {
+	case r.R >= len(r.Buf):
+		return nil, io.EOF
+	case len(r.Buf)-r.R < n:
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	r.R += n
+
+	return r.Buf[r.R-n : r.R], nil
+}
+
+func (r *BufReader) varintErr(n int) error {
+	switch {
+	case n < 0:
+		return ErrOverflow
+	case r.R >= len(r.Buf):
+		return io.EOF
+	default:
+		return io.ErrUnexpectedEOF
+	}
+}
@@ -0,0 +1,87 @@
+package low
+
+import (
+	"io"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	var b Buf
+
+	b.AppendUvarint(300)
+	b.AppendVarint(-5)
+	b.AppendUint16BE(0x0102)
+	b.AppendUint32LE(0x01020304)
+	b.AppendUint64BE(1 << 60)
+	b.AppendFloat64LE(3.5)
+	b.AppendFloat32BE(-1.25)
+	b.AppendLenString("hello")
+	b.AppendLenBytes([]byte{1, 2, 3})
+
+	r := BufReader{Buf: b}
+
+	check := func(name string, v, exp any, err error) {
+		t.Helper()
+
+		if err != nil || v != exp {
+			t.Errorf("%v: %v %v, want %v", name, v, err, exp)
+		}
+	}
+
+	u, err := r.ReadUvarint()
+	check("uvarint", u, uint64(300), err)
+
+	i, err := r.ReadVarint()
+	check("varint", i, int64(-5), err)
+
+	u16, err := r.ReadUint16BE()
+	check("uint16", u16, uint16(0x0102), err)
+
+	u32, err := r.ReadUint32LE()
+	check("uint32", u32, uint32(0x01020304), err)
+
+	u64, err := r.ReadUint64BE()
+	check("uint64", u64, uint64(1<<60), err)
+
+	f64, err := r.ReadFloat64LE()
+	check("float64", f64, 3.5, err)
+
+	f32, err := r.ReadFloat32BE()
+	check("float32", f32, float32(-1.25), err)
+
+	s, err := r.ReadLenString()
+	check("string", s, "hello", err)
+
+	p, err := r.ReadLenBytes()
+	check("bytes", string(p), "\x01\x02\x03", err)
+
+	if _, err = r.ReadUint16LE(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestBinaryTruncated(t *testing.T) {
+	r := BufReader{Buf: Buf{0x01}}
+
+	if _, err := r.ReadUint32BE(); err != io.ErrUnexpectedEOF || r.R != 0 {
+		t.Errorf("uint32: %v %d", err, r.R)
+	}
+
+	r = BufReader{Buf: Buf{0x80}}
+
+	if _, err := r.ReadUvarint(); err != io.ErrUnexpectedEOF || r.R != 0 {
+		t.Errorf("uvarint: %v %d", err, r.R)
+	}
+
+	r = BufReader{Buf: Buf{0x05, 'a', 'b'}}
+
+	if _, err := r.ReadLenString(); err != io.ErrUnexpectedEOF || r.R != 0 {
+		t.Errorf("string: %v %d", err, r.R)
+	}
+
+	r = BufReader{Buf: Buf{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}}
+
+	if _, err := r.ReadUvarint(); err != ErrOverflow {
+		t.Errorf("overflow: %v", err)
+	}
+}
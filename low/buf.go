@@ -1,8 +1,11 @@
 package low
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"strconv"
+	"strings"
 )
 
 const Spaces = "                                                                                                                                "
@@ -65,6 +68,51 @@ func (w *Buf) NewLine() {
 	}
 }
 
+// AppendPad appends n spaces.
+func (w *Buf) AppendPad(n int) {
+	for n > len(Spaces) {
+		*w = append(*w, Spaces...)
+		n -= len(Spaces)
+	}
+
+	if n > 0 {
+		*w = append(*w, Spaces[:n]...)
+	}
+}
+
+// Indent appends level*width spaces.
+func (w *Buf) Indent(level, width int) {
+	w.AppendPad(level * width)
+}
+
+// AppendRepeat appends s n times.
+func (w *Buf) AppendRepeat(s string, n int) {
+	for i := 0; i < n; i++ {
+		*w = append(*w, s...)
+	}
+}
+
+// AppendQuoted appends s as a double-quoted Go string literal.
+func (w *Buf) AppendQuoted(s string) {
+	*w = strconv.AppendQuote(*w, s)
+}
+
+// TrimRight removes all trailing bytes contained in cutset.
+func (w *Buf) TrimRight(cutset string) {
+	l := len(*w)
+
+	for l > 0 && strings.IndexByte(cutset, (*w)[l-1]) >= 0 {
+		l--
+	}
+
+	*w = (*w)[:l]
+}
+
+// LastLine returns the part of the buffer after the last newline.
+func (w Buf) LastLine() []byte {
+	return w[bytes.LastIndexByte(w, '\n')+1:]
+}
+
 func (w Buf) ReadAt(p []byte, off int64) (int, error) {
 	if off >= int64(len(w)) {
 		return 0, io.EOF
@@ -0,0 +1,30 @@
+package low
+
+import "testing"
+
+func TestBufText(t *testing.T) {
+	var b Buf
+
+	b.Indent(2, 2)
+	b = append(b, "func"...)
+	b.AppendPad(1)
+	b.AppendQuoted("a\tb")
+	b.AppendRepeat("()", 2)
+	b = append(b, " \t"...)
+	b.TrimRight(" \t")
+	b.NewLine()
+
+	if exp := "    func \"a\\tb\"()()\n"; string(b) != exp {
+		t.Errorf("got %q, want %q", b, exp)
+	}
+
+	if l := b.LastLine(); len(l) != 0 {
+		t.Errorf("last line: %q", l)
+	}
+
+	b.AppendPad(len(Spaces) + 3)
+
+	if l := b.LastLine(); len(l) != len(Spaces)+3 {
+		t.Errorf("last line len: %d", len(l))
+	}
+}
@@ -0,0 +1,196 @@
+package low
+
+import (
+	"errors"
+	"io"
+	"net"
+)
+
+type (
+	// Chain is a buffer made of fixed-size chunks.
+	// It never moves written data, so it fits large payloads
+	// which are written out with writev (net.Buffers).
+	// Zero value is ready to use.
+	Chain struct {
+		// ChunkSize is the capacity of newly allocated chunks.
+		// DefaultChunkSize is used if zero.
+		ChunkSize int
+
+		chunks [][]byte
+		r      int // read offset in the first chunk
+	}
+)
+
+const DefaultChunkSize = 64 << 10
+
+func (c *Chain) Write(p []byte) (n int, err error) {
+	for len(p) != 0 {
+		last := c.last()
+
+		m := copy(last[len(last):cap(last)], p)
+		c.chunks[len(c.chunks)-1] = last[:len(last)+m]
+
+		n += m
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+func (c *Chain) WriteString(s string) (n int, err error) {
+	for len(s) != 0 {
+		last := c.last()
+
+		m := copy(last[len(last):cap(last)], s)
+		c.chunks[len(c.chunks)-1] = last[:len(last)+m]
+
+		n += m
+		s = s[m:]
+	}
+
+	return n, nil
+}
+
+// ReadFrom reads from r directly into chunks until EOF.
+func (c *Chain) ReadFrom(r io.Reader) (n int64, err error) {
+	for {
+		last := c.last()
+
+		m, err := r.Read(last[len(last):cap(last)])
+		c.chunks[len(c.chunks)-1] = last[:len(last)+m]
+		n += int64(m)
+
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// Read reads and consumes data from the Chain.
+func (c *Chain) Read(p []byte) (n int, err error) {
+	if c.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	for i, b := range c.chunks {
+		if i == 0 {
+			b = b[c.r:]
+		}
+
+		n += copy(p[n:], b)
+		if n == len(p) {
+			break
+		}
+	}
+
+	c.Discard(n)
+
+	return n, nil
+}
+
+// WriteTo writes and consumes all the data.
+// net.Buffers is used, so writev is called if w is a net.Conn.
+func (c *Chain) WriteTo(w io.Writer) (n int64, err error) {
+	bufs := c.Buffers()
+
+	n, err = bufs.WriteTo(w)
+	c.Discard(int(n))
+
+	return n, err
+}
+
+// Buffers returns unread data as net.Buffers.
+// Buffers are valid until the next Chain modification.
+func (c *Chain) Buffers() net.Buffers {
+	if len(c.chunks) == 0 {
+		return nil
+	}
+
+	bufs := make(net.Buffers, len(c.chunks))
+	copy(bufs, c.chunks)
+	bufs[0] = bufs[0][c.r:]
+
+	return bufs
+}
+
+// Discard skips the next n bytes.
+func (c *Chain) Discard(n int) {
+	for n > 0 && len(c.chunks) != 0 {
+		m := len(c.chunks[0]) - c.r
+		if m > n {
+			m = n
+		}
+
+		c.r += m
+		n -= m
+
+		if c.r < len(c.chunks[0]) {
+			break
+		}
+
+		if len(c.chunks) == 1 {
+			c.chunks[0] = c.chunks[0][:0]
+			c.r = 0
+
+			break
+		}
+
+		c.chunks[0] = nil
+		c.chunks = c.chunks[1:]
+		c.r = 0
+	}
+}
+
+// Bytes returns a contiguous copy of unread data.
+func (c *Chain) Bytes() []byte {
+	b := make([]byte, 0, c.Len())
+
+	for _, p := range c.Buffers() {
+		b = append(b, p...)
+	}
+
+	return b
+}
+
+// Reset drops all the data keeping the first chunk for reuse.
+func (c *Chain) Reset() {
+	if len(c.chunks) == 0 {
+		return
+	}
+
+	for i := 1; i < len(c.chunks); i++ {
+		c.chunks[i] = nil
+	}
+
+	c.chunks = c.chunks[:1]
+	c.chunks[0] = c.chunks[0][:0]
+	c.r = 0
+}
+
+// Len returns unread data length.
+func (c *Chain) Len() (n int) {
+	for _, b := range c.chunks {
+		n += len(b)
+	}
+
+	return n - c.r
+}
+
+func (c *Chain) LenF() float64 { return float64(c.Len()) }
+
+// last returns the last chunk having free space, allocating it if needed.
+func (c *Chain) last() []byte {
+	if l := len(c.chunks); l != 0 && len(c.chunks[l-1]) < cap(c.chunks[l-1]) {
+		return c.chunks[l-1]
+	}
+
+	size := CSel(c.ChunkSize > 0, c.ChunkSize, DefaultChunkSize)
+	b := make([]byte, 0, size)
+
+	c.chunks = append(c.chunks, b)
+
+	return b
+}
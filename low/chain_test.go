@@ -0,0 +1,57 @@
+package low
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	c := Chain{ChunkSize: 4}
+
+	_, _ = c.Write([]byte("0123456"))
+	_, _ = c.WriteString("789a")
+
+	n, err := c.ReadFrom(strings.NewReader("bcdef"))
+	if n != 5 || err != nil {
+		t.Errorf("read from: %v %v", n, err)
+	}
+
+	if c.Len() != 16 {
+		t.Errorf("len %d", c.Len())
+	}
+
+	p := make([]byte, 6)
+
+	m, err := c.Read(p)
+	if m != 6 || err != nil || string(p) != "012345" {
+		t.Errorf("read: %d %v %q", m, err, p[:m])
+	}
+
+	if s := string(c.Bytes()); s != "6789abcdef" {
+		t.Errorf("bytes: %q", s)
+	}
+
+	var b bytes.Buffer
+
+	n, err = c.WriteTo(&b)
+	if n != 10 || err != nil || b.String() != "6789abcdef" {
+		t.Errorf("write to: %d %v %q", n, err, b.Bytes())
+	}
+
+	if c.Len() != 0 || len(c.chunks) != 1 {
+		t.Errorf("expected empty chain: %d %d", c.Len(), len(c.chunks))
+	}
+
+	if m, err = c.Read(p); m != 0 || err != io.EOF {
+		t.Errorf("read empty: %d %v", m, err)
+	}
+
+	_, _ = c.WriteString("abcdef")
+	c.Reset()
+
+	if c.Len() != 0 || len(c.chunks) != 1 {
+		t.Errorf("expected reset chain: %d %d", c.Len(), len(c.chunks))
+	}
+}
@@ -0,0 +1,273 @@
+// Package lowtest helps to check low.Buf and other byte slices in tests.
+package lowtest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+
+	"nikand.dev/go/hacked/low"
+)
+
+type (
+	diffOp struct {
+		op   byte // ' ', '-' or '+'
+		line []byte
+	}
+)
+
+// Context is the number of unchanged lines printed around each change.
+const Context = 3
+
+// maxDiffCells limits memory used to compute a diff.
+const maxDiffCells = 1 << 24
+
+// Update makes Golden to rewrite golden files instead of comparing with them.
+var Update = flag.Bool("update-golden", false, "update golden files in testdata")
+
+// Equal reports an error with a diff if got is not equal to exp.
+//
+//	lowtest.Equal(t, buf, "expected text\n")
+func Equal(tb testing.TB, got []byte, exp string) bool {
+	tb.Helper()
+
+	if string(got) == exp {
+		return true
+	}
+
+	tb.Errorf("buffer mismatch:\n%s", Diff([]byte(exp), got))
+
+	return false
+}
+
+// Golden compares got with testdata/name file.
+// The file is rewritten if -update-golden flag is set.
+func Golden(tb testing.TB, got []byte, name string) bool {
+	tb.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *Update {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			tb.Errorf("update golden file: %v", err)
+			return false
+		}
+
+		return true
+	}
+
+	exp, err := os.ReadFile(path)
+	if err != nil {
+		tb.Errorf("read golden file: %v (run with -update-golden to create it)", err)
+		return false
+	}
+
+	if bytes.Equal(got, exp) {
+		return true
+	}
+
+	tb.Errorf("golden file %v mismatch:\n%s", path, Diff(exp, got))
+
+	return false
+}
+
+// Diff returns unified diff of exp and got.
+// Binary data is compared as hex dumps.
+func Diff(exp, got []byte) string {
+	if isBinary(exp) || isBinary(got) {
+		exp = []byte(hex.Dump(exp))
+		got = []byte(hex.Dump(got))
+	}
+
+	var b low.Buf
+
+	b = append(b, "--- exp\n+++ got\n"...)
+
+	ops := diffLines(splitLines(exp), splitLines(got))
+
+	for st := 0; st < len(ops); {
+		for st < len(ops) && ops[st].op == ' ' {
+			st++
+		}
+		if st == len(ops) {
+			break
+		}
+
+		end := hunkEnd(ops, st)
+		st -= Context
+		if st < 0 {
+			st = 0
+		}
+
+		b = appendHunk(b, ops, st, end)
+
+		st = end
+	}
+
+	return string(b)
+}
+
+// hunkEnd returns the end of the hunk started with the change at st.
+// Changes separated by at most 2*Context unchanged lines are merged.
+func hunkEnd(ops []diffOp, st int) int {
+	end := st
+	same := 0
+
+	for i := st; i < len(ops) && same <= 2*Context; i++ {
+		if ops[i].op != ' ' {
+			end = i + 1
+			same = 0
+		} else {
+			same++
+		}
+	}
+
+	end += Context
+	if end > len(ops) {
+		end = len(ops)
+	}
+
+	return end
+}
+
+func appendHunk(b low.Buf, ops []diffOp, st, end int) low.Buf {
+	var e, g, el, gl int
+
+	for _, op := range ops[:st] {
+		e += b2i(op.op != '+')
+		g += b2i(op.op != '-')
+	}
+
+	for _, op := range ops[st:end] {
+		el += b2i(op.op != '+')
+		gl += b2i(op.op != '-')
+	}
+
+	b = fmt.Appendf(b, "@@ -%d,%d +%d,%d @@\n", e+b2i(el != 0), el, g+b2i(gl != 0), gl)
+
+	for _, op := range ops[st:end] {
+		b = append(b, op.op)
+		b = append(b, op.line...)
+
+		if len(op.line) == 0 || op.line[len(op.line)-1] != '\n' {
+			b = append(b, "\n\\ No newline at end of file\n"...)
+		}
+	}
+
+	return b
+}
+
+// diffLines computes the longest common subsequence based edit script.
+func diffLines(exp, got [][]byte) []diffOp {
+	pref := 0
+	for pref < len(exp) && pref < len(got) && bytes.Equal(exp[pref], got[pref]) {
+		pref++
+	}
+
+	suff := 0
+	for suff < len(exp)-pref && suff < len(got)-pref && bytes.Equal(exp[len(exp)-1-suff], got[len(got)-1-suff]) {
+		suff++
+	}
+
+	ops := make([]diffOp, 0, len(exp)+len(got))
+
+	for _, l := range exp[:pref] {
+		ops = append(ops, diffOp{op: ' ', line: l})
+	}
+
+	e, g := exp[pref:len(exp)-suff], got[pref:len(got)-suff]
+
+	if (len(e)+1)*(len(g)+1) > maxDiffCells {
+		for _, l := range e {
+			ops = append(ops, diffOp{op: '-', line: l})
+		}
+		for _, l := range g {
+			ops = append(ops, diffOp{op: '+', line: l})
+		}
+	} else {
+		ops = appendLCS(ops, e, g)
+	}
+
+	for _, l := range exp[len(exp)-suff:] {
+		ops = append(ops, diffOp{op: ' ', line: l})
+	}
+
+	return ops
+}
+
+func appendLCS(ops []diffOp, e, g [][]byte) []diffOp {
+	w := len(g) + 1
+	lcs := make([]int32, (len(e)+1)*w)
+
+	for i := len(e) - 1; i >= 0; i-- {
+		for j := len(g) - 1; j >= 0; j-- {
+			if bytes.Equal(e[i], g[j]) {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else if lcs[(i+1)*w+j] > lcs[i*w+j+1] {
+				lcs[i*w+j] = lcs[(i+1)*w+j]
+			} else {
+				lcs[i*w+j] = lcs[i*w+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(e) || j < len(g) {
+		switch {
+		case i < len(e) && j < len(g) && bytes.Equal(e[i], g[j]):
+			ops = append(ops, diffOp{op: ' ', line: e[i]})
+			i++
+			j++
+		case j == len(g) || i < len(e) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			ops = append(ops, diffOp{op: '-', line: e[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{op: '+', line: g[j]})
+			j++
+		}
+	}
+
+	return ops
+}
+
+func splitLines(b []byte) (r [][]byte) {
+	for len(b) != 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+
+		r = append(r, b[:i])
+		b = b[i:]
+	}
+
+	return r
+}
+
+func isBinary(b []byte) bool {
+	if !utf8.Valid(b) {
+		return true
+	}
+
+	for _, c := range b {
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' || c == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
+
+func b2i(v bool) int {
+	return low.CSel(v, 1, 0)
+}
@@ -0,0 +1,124 @@
+package lowtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nikand.dev/go/hacked/low"
+)
+
+type fakeTB struct {
+	testing.TB
+
+	msgs []string
+}
+
+func TestEqual(t *testing.T) {
+	var b low.Buf
+
+	b = append(b, "a\nb\nc\n"...)
+
+	var tb fakeTB
+
+	if !Equal(&tb, b, "a\nb\nc\n") || len(tb.msgs) != 0 {
+		t.Errorf("unexpected mismatch: %q", tb.msgs)
+	}
+
+	if Equal(&tb, b, "a\nx\nc\n") || len(tb.msgs) != 1 {
+		t.Fatalf("expected mismatch")
+	}
+
+	exp := "buffer mismatch:\n--- exp\n+++ got\n@@ -1,3 +1,3 @@\n a\n-x\n+b\n c\n"
+	if tb.msgs[0] != exp {
+		t.Errorf("diff:\n%s\nwant:\n%s", tb.msgs[0], exp)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		exp, got, diff string
+	}{
+		{
+			exp:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			got:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11",
+			diff: "--- exp\n+++ got\n@@ -8,4 +8,4 @@\n 8\n 9\n 10\n-11\n+11\n\\ No newline at end of file\n",
+		},
+		{
+			exp:  "a\n",
+			got:  "",
+			diff: "--- exp\n+++ got\n@@ -1,1 +0,0 @@\n-a\n",
+		},
+		{
+			exp:  "\x00\x01",
+			got:  "\x00\x02",
+			diff: "--- exp\n+++ got\n@@ -1,1 +1,1 @@\n-00000000  00 01                                             |..|\n+00000000  00 02                                             |..|\n",
+		},
+	} {
+		d := Diff([]byte(tc.exp), []byte(tc.got))
+		if d != tc.diff {
+			t.Errorf("diff %q %q:\n%s\nwant:\n%s", tc.exp, tc.got, d, tc.diff)
+		}
+	}
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.msgs = append(tb.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestGolden(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+
+	defer func(v bool) {
+		*Update = v
+	}(*Update)
+
+	var tb fakeTB
+
+	*Update = false
+
+	if Golden(&tb, []byte("a\n"), "sub/out.txt") || len(tb.msgs) != 1 {
+		t.Fatalf("expected missing golden file error: %q", tb.msgs)
+	}
+
+	*Update = true
+
+	if !Golden(&tb, []byte("a\nb\n"), "sub/out.txt") || len(tb.msgs) != 1 {
+		t.Fatalf("update: %q", tb.msgs)
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", "sub", "out.txt"))
+	if string(data) != "a\nb\n" || err != nil {
+		t.Errorf("golden file: %q %v", data, err)
+	}
+
+	*Update = false
+
+	if !Golden(&tb, []byte("a\nb\n"), "sub/out.txt") || len(tb.msgs) != 1 {
+		t.Errorf("unexpected mismatch: %q", tb.msgs)
+	}
+
+	if Golden(&tb, []byte("a\nc\n"), "sub/out.txt") || len(tb.msgs) != 2 {
+		t.Fatalf("expected mismatch: %q", tb.msgs)
+	}
+
+	exp := "golden file testdata/sub/out.txt mismatch:\n--- exp\n+++ got\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	if tb.msgs[1] != filepath.FromSlash(exp) {
+		t.Errorf("diff:\n%s\nwant:\n%s", tb.msgs[1], exp)
+	}
+}
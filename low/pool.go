@@ -0,0 +1,124 @@
+package low
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// Pool is a Buf allocator with power-of-two size classes.
+	// Zero value is ready to use.
+	Pool struct {
+		// MaxCap is the largest buffer capacity kept in the Pool.
+		// Bigger buffers are allocated on demand and dropped on Put.
+		// It's rounded down to a power of two.
+		// DefaultPoolMaxCap is used if zero.
+		MaxCap int
+
+		classes [poolClasses]sync.Pool
+
+		hits   atomic.Int64
+		misses atomic.Int64
+		puts   atomic.Int64
+		drops  atomic.Int64
+	}
+
+	PoolStats struct {
+		Hits   int64 // Get returned pooled buffer
+		Misses int64 // Get allocated new buffer
+		Puts   int64 // Put returned buffer to the pool
+		Drops  int64 // Put dropped too small or too big buffer
+	}
+)
+
+const (
+	poolMinBits = 6  // 64B
+	poolMaxBits = 30 // 1GiB
+	poolClasses = poolMaxBits - poolMinBits + 1
+
+	DefaultPoolMaxCap = 1 << 20
+)
+
+// Get returns empty Buf with at least minCap capacity.
+// It should be returned with Put when no longer needed.
+func (p *Pool) Get(minCap int) *Buf {
+	if minCap > p.maxCap() {
+		p.misses.Add(1)
+
+		b := make(Buf, 0, minCap)
+		return &b
+	}
+
+	c := poolClassUp(minCap)
+
+	if b, ok := p.classes[c].Get().(*Buf); ok {
+		p.hits.Add(1)
+
+		*b = (*b)[:0]
+		return b
+	}
+
+	p.misses.Add(1)
+
+	b := make(Buf, 0, 1<<(c+poolMinBits))
+	return &b
+}
+
+// Put returns b to the pool.
+// Buffers larger than MaxCap or smaller than the minimal class are dropped.
+// b must not be used after that.
+func (p *Pool) Put(b *Buf) {
+	if b == nil {
+		return
+	}
+
+	c := cap(*b)
+	if c < 1<<poolMinBits || c > p.maxCap() {
+		p.drops.Add(1)
+		return
+	}
+
+	p.puts.Add(1)
+
+	*b = (*b)[:0]
+	p.classes[poolClassDown(c)].Put(b)
+}
+
+// Stats returns pool usage counters.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Hits:   p.hits.Load(),
+		Misses: p.misses.Load(),
+		Puts:   p.puts.Load(),
+		Drops:  p.drops.Load(),
+	}
+}
+
+func (p *Pool) maxCap() int {
+	switch {
+	case p.MaxCap == 0:
+		return DefaultPoolMaxCap
+	case p.MaxCap > 1<<poolMaxBits:
+		return 1 << poolMaxBits
+	case p.MaxCap < 1<<poolMinBits:
+		return 1 << poolMinBits
+	default:
+		// the largest class boundary, so Get never returns a buffer Put drops
+		return 1 << (bits.Len(uint(p.MaxCap)) - 1)
+	}
+}
+
+// poolClassUp returns the smallest class which fits n bytes.
+func poolClassUp(n int) int {
+	if n <= 1<<poolMinBits {
+		return 0
+	}
+
+	return bits.Len(uint(n-1)) - poolMinBits
+}
+
+// poolClassDown returns the largest class which buffer of capacity n satisfies.
+func poolClassDown(n int) int {
+	return bits.Len(uint(n)) - 1 - poolMinBits
+}
@@ -0,0 +1,75 @@
+package low
+
+import "testing"
+
+func TestPool(t *testing.T) {
+	var p Pool
+
+	b := p.Get(100)
+	if cap(*b) != 128 || len(*b) != 0 {
+		t.Errorf("get 100: len %d cap %d", len(*b), cap(*b))
+	}
+
+	*b = append(*b, "some data"...)
+	p.Put(b)
+
+	huge := p.Get(2 * DefaultPoolMaxCap)
+	if cap(*huge) != 2*DefaultPoolMaxCap {
+		t.Errorf("get huge: cap %d", cap(*huge))
+	}
+
+	p.Put(huge)
+
+	small := make(Buf, 0, 10)
+	p.Put(&small)
+
+	exp := PoolStats{Misses: 2, Puts: 1, Drops: 2}
+	if s := p.Stats(); s != exp {
+		t.Errorf("stats: %+v, want %+v", s, exp)
+	}
+}
+
+func TestPoolHits(t *testing.T) {
+	p := Pool{MaxCap: 1000} // rounded down to 512
+
+	b := p.Get(900)
+	if cap(*b) != 900 {
+		t.Errorf("get 900: cap %d", cap(*b))
+	}
+
+	p.Put(b)
+
+	// sync.Pool may drop buffers, especially with -race, so try a few times
+	for i := 0; i < 20; i++ {
+		b := p.Get(500)
+		if cap(*b) != 512 {
+			t.Fatalf("get 500: cap %d", cap(*b))
+		}
+
+		p.Put(b)
+	}
+
+	s := p.Stats()
+	if s.Hits == 0 || s.Drops != 1 || s.Puts != 20 || s.Hits+s.Misses != 21 {
+		t.Errorf("stats: %+v", s)
+	}
+}
+
+func TestPoolClass(t *testing.T) {
+	for _, tc := range []struct {
+		n, up, down int
+	}{
+		{n: 64, up: 0, down: 0},
+		{n: 65, up: 1, down: 0},
+		{n: 127, up: 1, down: 0},
+		{n: 128, up: 1, down: 1},
+		{n: 1 << 20, up: 14, down: 14},
+	} {
+		if c := poolClassUp(tc.n); c != tc.up {
+			t.Errorf("class up %d: %d, want %d", tc.n, c, tc.up)
+		}
+		if c := poolClassDown(tc.n); c != tc.down {
+			t.Errorf("class down %d: %d, want %d", tc.n, c, tc.down)
+		}
+	}
+}